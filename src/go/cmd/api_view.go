@@ -5,7 +5,6 @@ package cmd
 
 import (
 	"encoding/json"
-	"os"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -13,17 +12,11 @@ import (
 
 // CreateAPIView generates the output file that the API view tool uses.
 func CreateAPIView(pkgDir, outputDir string) error {
-	review, err := createReview(pkgDir)
-	if err != nil {
-		panic(err)
-	}
-	filename := filepath.Join(outputDir, review.Name+".json")
-	file, _ := json.MarshalIndent(review, "", " ")
-	err = os.WriteFile(filename, file, 0644)
+	review, err := generateReview(pkgDir)
 	if err != nil {
 		return err
 	}
-	return nil
+	return writeReviewFile(filepath.Join(outputDir, reviewFileName(review)), review)
 }
 
 func createReview(pkgDir string) (CodeFile, error) {
@@ -34,6 +27,23 @@ func createReview(pkgDir string) (CodeFile, error) {
 	return r.Review()
 }
 
+// reviewModule returns a review of the module in dir, and that module's path. When version
+// isn't empty, it overrides the module's version.
+func reviewModule(dir, version string) (CodeFile, string, error) {
+	return reviewModuleInIndex(newModuleIndex(), dir, version)
+}
+
+// reviewModuleInIndex is reviewModule for reviews sharing index
+func reviewModuleInIndex(index *moduleIndex, dir, version string) (CodeFile, string, error) {
+	r, err := newReview(dir, index)
+	if err != nil {
+		return CodeFile{}, "", err
+	}
+	r.version = version
+	cf, err := r.Review()
+	return cf, r.reviewed.ModFile.Module.Mod.Path, err
+}
+
 func recursiveSortNavigation(n NavigationItem) {
 	for _, nn := range n.ChildItems {
 		recursiveSortNavigation(nn)
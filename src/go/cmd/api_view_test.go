@@ -8,6 +8,8 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -273,6 +275,40 @@ func TestAliasDiagnostics(t *testing.T) {
 	}
 }
 
+func TestInternalTypeLeaks(t *testing.T) {
+	review, err := createReview(filepath.Clean("testdata/test_internal_leak"))
+	require.NoError(t, err)
+	secret := exposesInternalType + "internal/impl.Secret"
+	expected := map[string][]string{
+		"test_internal_leak-(o *Options) Secret": {secret},
+		"test_internal_leak-(w Widget) Reveal":   {missingAliasFor + "Secret"},
+		"test_internal_leak-New":                 {secret},
+		"test_internal_leak.Default":             {secret},
+		"test_internal_leak.Getter-Get":          {secret},
+		"test_internal_leak.Handler":             {secret},
+		"test_internal_leak.Options":             {exposesInternalType + "internal/impl.Base"},
+		"test_internal_leak.Options-Setting":     {secret},
+		"test_internal_leak.Widget":              {missingAliasFor + "Part"},
+	}
+	actual := map[string][]string{}
+	for _, d := range review.Diagnostics {
+		if d.Level == CodeDiagnosticLevelInfo {
+			require.True(t, strings.HasPrefix(d.Text, aliasFor), d.Text)
+			continue
+		}
+		require.Equal(t, CodeDiagnosticLevelError, d.Level)
+		actual[d.TargetID] = append(actual[d.TargetID], d.Text)
+	}
+	require.Equal(t, expected, actual)
+
+	// each diagnostic targets a line of the review
+	lineIDs := map[string]bool{}
+	forAll(review.ReviewLines, func(ln ReviewLine) { lineIDs[ln.LineID] = true })
+	for id := range expected {
+		require.True(t, lineIDs[id], id)
+	}
+}
+
 func TestMajorVersion(t *testing.T) {
 	review, err := createReview(filepath.Clean("testdata/test_major_version"))
 	require.NoError(t, err)
@@ -303,6 +339,123 @@ func TestVars(t *testing.T) {
 	require.True(t, hasHTTPClient)
 }
 
+func TestDocumentation(t *testing.T) {
+	review, err := createReview(filepath.Clean("testdata/test_doc"))
+	require.NoError(t, err)
+	// docs maps LineIDs to the documentation lines related to them
+	docs := map[string][]string{}
+	lineIDs := map[string]bool{}
+	forAll(review.ReviewLines, func(rl ReviewLine) {
+		lineIDs[rl.LineID] = true
+		for _, tk := range rl.Tokens {
+			if tk.IsDocumentation {
+				require.Equal(t, TokenKindComment, tk.Kind)
+				require.NotEmpty(t, rl.RelatedToLine, "documentation line should be related to a declaration")
+				docs[rl.RelatedToLine] = append(docs[rl.RelatedToLine], tk.Value)
+			}
+		}
+	})
+	for id := range docs {
+		require.True(t, lineIDs[id], "no LineID corresponds to RelatedToLine %q", id)
+	}
+	for id, expected := range map[string][]string{
+		"test_doc":                  {"// Package test_doc has documented APIs."},
+		"test_doc.Color":            {"/*", "Color is a documented simple type.", "*/"},
+		"test_doc.ColorRed":         {"// ColorRed is red."},
+		"test_doc.DefaultColor":     {"// DefaultColor is the default color."},
+		"test_doc.Spinner":          {"// Spinner is a documented interface."},
+		"test_doc.Spinner-Spin":     {"// Spin spins something."},
+		"test_doc.Version":          {"// Version is a documented untyped const."},
+		"test_doc.Widget":           {"// Widget is a documented struct."},
+		"test_doc.Widget-Name":      {"// Name is the widget's name."},
+		"test_doc-(w *Widget) Spin": {"// Spin spins the widget.", "//"},
+		"test_doc-NewWidget":        {"// NewWidget creates a Widget."},
+	} {
+		require.Equal(t, expected, docs[id], id)
+	}
+	require.NotContains(t, docs, "test_doc.ColorBlue")
+	require.NotContains(t, docs, "test_doc.Widget-Undocumented")
+
+	// documentation should immediately precede the line it describes
+	var check func([]ReviewLine)
+	check = func(lines []ReviewLine) {
+		for i, ln := range lines {
+			if ln.RelatedToLine != "" && len(ln.Tokens) > 0 && ln.Tokens[0].IsDocumentation {
+				j := i + 1
+				for j < len(lines) && lines[j].RelatedToLine == ln.RelatedToLine && lines[j].LineID == "" {
+					j++
+				}
+				require.Less(t, j, len(lines))
+				require.Equal(t, ln.RelatedToLine, lines[j].LineID)
+			}
+			check(ln.Children)
+		}
+	}
+	check(review.ReviewLines)
+}
+
+func TestDeprecated(t *testing.T) {
+	review, err := createReview(filepath.Clean("testdata/test_deprecated"))
+	require.NoError(t, err)
+	expected := map[string]string{
+		"test_deprecated-(Hoisted) Method": "Deprecated: use something else.",
+		"test_deprecated-(c *Client) Do":   "Deprecated: Do is going away.",
+		"test_deprecated-Func":             "Deprecated: this is deprecated.",
+		"test_deprecated.Client":           "Deprecated: use NewClient instead.",
+		"test_deprecated.Client-Endpoint":  "Deprecated: set URL instead.",
+		"test_deprecated.Color":            "Deprecated: use string instead.",
+		"test_deprecated.ColorRed":         `Deprecated: use "red" instead.`,
+		"test_deprecated.Doer-Do":          "Deprecated: no longer necessary.",
+	}
+	actual := map[string]string{}
+	for _, d := range review.Diagnostics {
+		// Client.Do also violates a lint rule
+		if d.DiagnosticID != deprecatedID {
+			continue
+		}
+		require.Equal(t, CodeDiagnosticLevelInfo, d.Level)
+		actual[d.TargetID] = d.Text
+	}
+	require.Equal(t, expected, actual)
+
+	// each deprecated API's line should have a deprecated name token
+	forAll(review.ReviewLines, func(rl ReviewLine) {
+		if rl.LineID == "" {
+			return
+		}
+		deprecated := false
+		for _, tk := range rl.Tokens {
+			if tk.IsDeprecated {
+				require.False(t, deprecated, "%s has multiple deprecated tokens", rl.LineID)
+				deprecated = true
+			}
+		}
+		_, ok := expected[rl.LineID]
+		require.Equal(t, ok, deprecated, rl.LineID)
+	})
+}
+
+func TestPackageVersion(t *testing.T) {
+	for _, test := range []struct {
+		name, path, override, expected string
+	}{
+		{name: "moduleVersion const", path: "testdata/test_version", expected: "v1.2.3"},
+		{name: "module cache", path: "testdata/test_package_name/test_package_name@v1.0.0", expected: "v1.0.0"},
+		{name: "override", path: "testdata/test_version", override: "v2.0.0-beta.1", expected: "v2.0.0-beta.1"},
+		{name: "unknown", path: "testdata/test_subpackage"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			review, _, err := reviewModule(filepath.Clean(test.path), test.override)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, review.PackageVersion)
+		})
+	}
+	t.Run("invalid", func(t *testing.T) {
+		_, _, err := reviewModule(filepath.Clean("testdata/test_version"), "1.2.3")
+		require.ErrorContains(t, err, "invalid version")
+	})
+}
+
 func Test_getPackageNameFromModPath(t *testing.T) {
 	require.EqualValues(t, "foo", getPackageNameFromModPath("foo"))
 	require.EqualValues(t, "foo", getPackageNameFromModPath("foo/v2"))
@@ -353,3 +506,498 @@ func searchTokens(lines []ReviewLine, match func(ReviewToken) bool) bool {
 		return false
 	})
 }
+
+func TestTypeCheck(t *testing.T) {
+	typeCheck = true
+	defer func() { typeCheck = false }()
+	modCache, err := filepath.Abs(filepath.Join("testdata", "test_typecheck", "modcache"))
+	require.NoError(t, err)
+	t.Setenv("GOMODCACHE", modCache)
+	review, err := createReview(filepath.Clean("testdata/test_typecheck"))
+	require.NoError(t, err)
+	expected := map[string]struct {
+		text  string
+		links []string
+	}{
+		// dot import
+		"test_typecheck-NewClient": {
+			text:  "func NewClient(o *Options) *Client",
+			links: []string{"Options>test_typecheck/sub.Options", "Client>test_typecheck.Client"},
+		},
+		// func-typed param and aliased import
+		"test_typecheck-(c *Client) Callback": {
+			text:  "func (*Client) Callback(fn func(o s.Options) error) error",
+			links: []string{"Client>test_typecheck.Client", "s.Options>test_typecheck/sub.Options"},
+		},
+		// type param shadowing a type
+		"test_typecheck-Convert": {
+			text: "func Convert[Client any](c Client) Client",
+		},
+		// standard library types
+		"test_typecheck-(c *Client) Do": {
+			text:  "func (*Client) Do(req *http.Request) (*http.Response, error)",
+			links: []string{"Client>test_typecheck.Client"},
+		},
+		"test_typecheck.Client-Options": {
+			text:  "Options *Options",
+			links: []string{"Options>test_typecheck/sub.Options"},
+		},
+		// nested generics
+		"test_typecheck.Client-Pairs": {
+			text:  "Pairs Pair[string, Pair[int, *Client]]",
+			links: []string{"Pair>test_typecheck/sub.Pair", "Pair>test_typecheck/sub.Pair", "Client>test_typecheck.Client"},
+		},
+		// predeclared constraint
+		"test_typecheck/sub.Pair": {
+			text: "type Pair[K comparable, V any] struct",
+		},
+		// types from a replaced module and a module in the module cache
+		"test_typecheck-(c *Client) Connect": {
+			text:  "func (*Client) Connect(o dep.Options, l Level, retry func(attempt int) bool) *dep.Conn",
+			links: []string{"Client>test_typecheck.Client"},
+		},
+		// aliased standard library import
+		"test_typecheck-(c *Client) Run": {
+			text:  "func (*Client) Run(c2 ctx.Context) error",
+			links: []string{"Client>test_typecheck.Client"},
+		},
+	}
+	found := 0
+	forAll(review.ReviewLines, func(rl ReviewLine) {
+		exp, ok := expected[rl.LineID]
+		if !ok {
+			return
+		}
+		found++
+		require.Equal(t, exp.text, lineText(rl, nil))
+		links := []string{}
+		for _, tk := range rl.Tokens {
+			if tk.NavigateToID != "" {
+				links = append(links, tk.Value+">"+tk.NavigateToID)
+			}
+		}
+		require.ElementsMatch(t, exp.links, links, rl.LineID)
+		if rl.LineID == "test_typecheck-(c *Client) Connect" {
+			// tokens come from the param's type, not its text, so the func param's name is a member
+			i := slices.IndexFunc(rl.Tokens, func(tk ReviewToken) bool { return tk.Value == "attempt" })
+			require.NotEqual(t, -1, i)
+			require.Equal(t, TokenKindMemberName, rl.Tokens[i].Kind)
+		}
+	})
+	require.Equal(t, len(expected), found)
+
+	// type checking doesn't change the text of the review, only its links
+	typeCheck = false
+	untyped, err := createReview(filepath.Clean("testdata/test_typecheck"))
+	require.NoError(t, err)
+	declarations := func(cf CodeFile) map[string]string {
+		texts := map[string]string{}
+		forAll(cf.ReviewLines, func(rl ReviewLine) {
+			if len(rl.Tokens) > 0 && !rl.Tokens[0].IsDocumentation {
+				texts[rl.LineID] = lineText(rl, nil)
+			}
+		})
+		return texts
+	}
+	require.Equal(t, declarations(untyped), declarations(review))
+}
+
+func TestBuildConstraints(t *testing.T) {
+	defer func() { goos, buildTags = "", nil }()
+	for _, test := range []struct {
+		goos, handle string
+		tags         []string
+		want         []string
+	}{
+		{goos: "linux", handle: "type Handle int", want: []string{"test_platforms-Epoll"}},
+		{goos: "windows", handle: "type Handle uintptr", want: []string{"test_platforms-OpenFile"}},
+		{goos: "darwin", handle: "type Handle int", tags: []string{"extras"}, want: []string{"test_platforms-Extra"}},
+	} {
+		t.Run(test.goos, func(t *testing.T) {
+			goos, buildTags = test.goos, test.tags
+			review, err := createReview(filepath.Clean("testdata/test_platforms"))
+			require.NoError(t, err)
+			funcs := []string{}
+			forAll(review.ReviewLines, func(rl ReviewLine) {
+				switch {
+				case rl.LineID == "test_platforms.Handle":
+					require.Equal(t, test.handle, lineText(rl, nil))
+				case strings.HasPrefix(rl.LineID, "test_platforms-"):
+					funcs = append(funcs, rl.LineID)
+				}
+			})
+			require.ElementsMatch(t, test.want, funcs)
+		})
+	}
+}
+
+func TestPlatforms(t *testing.T) {
+	platforms = []string{"linux/amd64", "darwin/arm64", "windows/amd64"}
+	defer func() { platforms = nil }()
+	review, err := createReview(filepath.Clean("testdata/test_platforms"))
+	require.NoError(t, err)
+	// the review has the union of the platforms' declarations
+	funcs := []string{}
+	forAll(review.ReviewLines, func(rl ReviewLine) {
+		if strings.HasPrefix(rl.LineID, "test_platforms-") {
+			funcs = append(funcs, rl.LineID)
+		}
+	})
+	require.ElementsMatch(t, []string{"test_platforms-Epoll", "test_platforms-OpenFile"}, funcs)
+	require.ElementsMatch(t, []CodeDiagnostic{
+		{
+			DiagnosticID: platformOnlyID,
+			Level:        CodeDiagnosticLevelInfo,
+			TargetID:     "test_platforms-Epoll",
+			Text:         declaredOnlyFor + "linux/amd64",
+		},
+		{
+			DiagnosticID: platformOnlyID,
+			Level:        CodeDiagnosticLevelInfo,
+			TargetID:     "test_platforms-OpenFile",
+			Text:         declaredOnlyFor + "windows/amd64",
+		},
+		{
+			DiagnosticID: platformOnlyID,
+			Level:        CodeDiagnosticLevelInfo,
+			TargetID:     "test_platforms.Event",
+			Text:         declaredOnlyFor + "windows/amd64",
+		},
+		{
+			DiagnosticID: platformDifferenceID,
+			Level:        CodeDiagnosticLevelInfo,
+			TargetID:     "test_platforms.Handle",
+			Text:         declaredDifferentlyFor + "linux/amd64, darwin/arm64: type Handle int; windows/amd64: type Handle uintptr",
+		},
+		{
+			DiagnosticID: platformOnlyID,
+			Level:        CodeDiagnosticLevelInfo,
+			TargetID:     "test_platforms.Overlapped",
+			Text:         declaredOnlyFor + "windows/amd64",
+		},
+		{
+			DiagnosticID: aliasID,
+			Level:        CodeDiagnosticLevelWarning,
+			TargetID:     "test_platforms.Overlapped",
+			Text:         aliasFor + "example.com/winapi.Overlapped",
+		},
+	}, review.Diagnostics)
+	// the review has the definition of an alias declared only for windows
+	fields := []string{}
+	forAll(review.ReviewLines, func(rl ReviewLine) {
+		if strings.HasPrefix(rl.LineID, "test_platforms.Overlapped-") {
+			fields = append(fields, rl.LineID)
+		}
+	})
+	require.Equal(t, []string{"test_platforms.Overlapped-Offset"}, fields)
+
+	// types declared only for some platforms are definitions for aliases in other modules
+	m, err := NewModule(filepath.Join("testdata", "test_platforms"))
+	require.NoError(t, err)
+	p := m.Packages["github.com/Azure/azure-sdk-tools/src/go/cmd/testdata/test_platforms"]
+	require.NotNil(t, p)
+	_, ok := recursiveFindTypeDef("Event", p, m.Packages)
+	require.True(t, ok)
+
+	platforms = []string{"linux"}
+	_, err = createReview(filepath.Clean("testdata/test_platforms"))
+	require.ErrorContains(t, err, `invalid platform "linux"`)
+}
+
+func TestConsts(t *testing.T) {
+	expected := map[string]string{
+		// implicitly repeated iota
+		"test_consts.Red":   "Red Color = 0",
+		"test_consts.Green": "Green Color = 1",
+		"test_consts.Blue":  "Blue Color = 2",
+		"test_consts.FlagA": "FlagA Flag = 1",
+		"test_consts.FlagB": "FlagB Flag = 2",
+		"test_consts.FlagC": "FlagC Flag = 4",
+		"test_consts.KB":    "KB = 1024",
+		"test_consts.MB":    "MB = 1048576",
+		// evaluated expression
+		"test_consts.Greeting": `Greeting = "hello, world"`,
+		// literal as written
+		"test_consts.Hex": "Hex = 0x10",
+		// multiple names
+		"test_consts.Max": "Max = 10",
+		"test_consts.Min": "Min = -10",
+		"test_consts.A":   "A = 1",
+		"test_consts.B":   "B = 2",
+		"test_consts.X":   "X int = pair()",
+		"test_consts.Y":   "Y int = pair()",
+	}
+	for _, tc := range []bool{false, true} {
+		typeCheck = tc
+		review, err := createReview(filepath.Clean("testdata/test_consts"))
+		require.NoError(t, err)
+		actual := map[string]string{}
+		forAll(review.ReviewLines, func(rl ReviewLine) {
+			if _, ok := expected[rl.LineID]; ok {
+				actual[rl.LineID] = lineText(rl, nil)
+			}
+		})
+		require.Equal(t, expected, actual, "typeCheck=%t", tc)
+	}
+	typeCheck = false
+}
+
+func TestInferDeclarationTypes(t *testing.T) {
+	expected := map[string]struct {
+		parent, text, link string
+	}{
+		"test_infer.DefaultOptions": {parent: "test_infer.Options", text: "DefaultOptions Options = NewOptions()", link: "test_infer.Options"},
+		"test_infer.Fallback":       {parent: "test_infer.Options", text: "Fallback *Options = fallback()", link: "test_infer.Options"},
+		"test_infer.Red":            {parent: "test_infer.Color", text: `Red Color = "red"`, link: "test_infer.Color"},
+		"test_infer.Blue":           {text: `Blue sub.Color = sub.Color("blue")`, link: "test_infer/sub.Color"},
+		"test_infer.Count":          {text: "Count int64 = int64(3)"},
+		"test_infer.DefaultTimeout": {text: "DefaultTimeout sub.Timeout = sub.NewTimeout()", link: "test_infer/sub.Timeout"},
+	}
+	for _, tc := range []bool{false, true} {
+		typeCheck = tc
+		review, err := createReview(filepath.Clean("testdata/test_infer"))
+		require.NoError(t, err)
+		found := 0
+		var visit func(lines []ReviewLine, parent string)
+		visit = func(lines []ReviewLine, parent string) {
+			for _, rl := range lines {
+				if exp, ok := expected[rl.LineID]; ok {
+					found++
+					require.Equal(t, exp.parent, parent, rl.LineID)
+					require.Equal(t, exp.text, lineText(rl, nil))
+					link := ""
+					for _, tk := range rl.Tokens[1:] {
+						if tk.NavigateToID != "" {
+							link = tk.NavigateToID
+						}
+					}
+					require.Equal(t, exp.link, link, rl.LineID)
+				}
+				p := parent
+				if strings.HasPrefix(rl.LineID, "test_infer.") {
+					p = rl.LineID
+				}
+				visit(rl.Children, p)
+			}
+		}
+		visit(review.ReviewLines, "")
+		require.Equal(t, len(expected), found, "typeCheck=%t", tc)
+	}
+	typeCheck = false
+}
+
+func TestStructTags(t *testing.T) {
+	defer func() { skipDiffTags = false }()
+	for _, skip := range []bool{false, true} {
+		skipDiffTags = skip
+		review, err := createReview(filepath.Clean("testdata/test_tags"))
+		require.NoError(t, err)
+		var model *ReviewLine
+		forAll(review.ReviewLines, func(rl ReviewLine) {
+			if rl.LineID == "test_tags.Model" {
+				model = &rl
+			}
+		})
+		require.NotNil(t, model)
+		type field struct {
+			text, link string
+		}
+		actual := []field{}
+		for _, rl := range model.Children {
+			f := field{text: lineText(rl, nil)}
+			for _, tk := range rl.Tokens {
+				if tk.NavigateToID != "" {
+					f.link = tk.NavigateToID
+				}
+				if tk.Kind == TokenKindStringLiteral {
+					require.Equal(t, skip, tk.SkipDiff)
+				}
+			}
+			if f.text != "" {
+				actual = append(actual, f)
+			}
+		}
+		require.Equal(t, []field{
+			// embedded fields link to their definitions
+			{text: "*sub.Remote `json:\"remote,omitempty\"`", link: "test_tags/sub.Remote"},
+			{text: "Base", link: "test_tags.Base"},
+			{text: "Count int"},
+			{text: "Name string `json:\"name\" xml:\"name,attr\"`"},
+		}, actual)
+	}
+}
+
+func TestGenerics(t *testing.T) {
+	review, err := createReview(filepath.Clean("testdata/test_generics"))
+	require.NoError(t, err)
+	type line struct {
+		children []string
+		links    []string
+		text     string
+	}
+	actual := map[string]line{}
+	forAll(review.ReviewLines, func(rl ReviewLine) {
+		if rl.LineID == "" {
+			return
+		}
+		l := line{text: lineText(rl, nil)}
+		for _, tk := range rl.Tokens {
+			if tk.NavigateToID != "" {
+				l.links = append(l.links, tk.Value+">"+tk.NavigateToID)
+			}
+		}
+		for _, c := range rl.Children {
+			if c.LineID != "" {
+				l.children = append(l.children, c.LineID)
+			}
+		}
+		actual[rl.LineID] = l
+	})
+	for id, expected := range map[string]line{
+		"test_generics.List": {text: "type List[T any] []T"},
+		"test_generics.Map":  {text: "type Map[K comparable, V any] map[K]V"},
+		"test_generics.Pager": {
+			text: "type Pager[T any] struct",
+			// methods having a generic receiver belong to the receiver's type
+			children: []string{"test_generics-(p *Pager[T]) More", "test_generics-(p *Pager[T]) NextPage"},
+		},
+		"test_generics.Pair": {
+			text:     "type Pair[K comparable, V any] struct",
+			children: []string{"test_generics.Pair-Key", "test_generics.Pair-Value", "test_generics-(p Pair[K, V]) Swap"},
+		},
+		"test_generics.Poller": {
+			text:     "type Poller[T any] interface",
+			children: []string{"test_generics.Poller-Result"},
+		},
+		"test_generics-(p *Pager[T]) More": {
+			text:  "func (*Pager[T]) More() bool",
+			links: []string{"Pager>test_generics.Pager"},
+		},
+		"test_generics-(p Pair[K, V]) Swap": {
+			text:  "func (Pair[K, V]) Swap() Pair[V, K]",
+			links: []string{"Pair>test_generics.Pair", "Pair>test_generics.Pair"},
+		},
+		"test_generics-(c *Client) NewListPager": {
+			text:  "func (*Client) NewListPager() *Pager[Page]",
+			links: []string{"Client>test_generics.Client", "Pager>test_generics.Pager", "Page>test_generics.Page"},
+		},
+	} {
+		require.Contains(t, actual, id)
+		require.Equal(t, expected, actual[id], id)
+	}
+}
+
+func TestConstraints(t *testing.T) {
+	review, err := createReview(filepath.Clean("testdata/test_constraints"))
+	require.NoError(t, err)
+	children := map[string][]string{}
+	forAll(review.ReviewLines, func(rl ReviewLine) {
+		if rl.LineID == "" || len(rl.Children) == 0 {
+			return
+		}
+		for _, c := range rl.Children {
+			if text := lineText(c, nil); text != "" {
+				children[rl.LineID] = append(children[rl.LineID], text)
+			}
+		}
+	})
+	require.Equal(t, []string{"~int | ~int64 | ~float64"}, children["test_constraints.Number"])
+	require.Equal(t, []string{"comparable", "Number"}, children["test_constraints.Key"])
+	require.Equal(t, []string{"~string"}, children["test_constraints/sub.ID"])
+
+	kinds := map[string]string{}
+	for _, n := range review.Navigation {
+		for _, item := range n.ChildItems {
+			kinds[item.NavigationID] = (*item.Tags)["TypeKind"]
+		}
+	}
+	require.Equal(t, "constraint", kinds["test_constraints.Key"])
+	require.Equal(t, "constraint", kinds["test_constraints.Number"])
+	require.Equal(t, "constraint", kinds["test_constraints/sub.ID"])
+	require.Equal(t, "interface", kinds["test_constraints.Stringer"])
+
+	uses := map[string]string{}
+	for _, d := range review.Diagnostics {
+		if strings.HasPrefix(d.Text, constraintOutsideTypeParams) {
+			require.Equal(t, CodeDiagnosticLevelError, d.Level)
+			uses[d.TargetID] = strings.TrimPrefix(d.Text, constraintOutsideTypeParams)
+		}
+	}
+	// using constraints in type parameter lists, and re-exporting them, is fine
+	require.Equal(t, map[string]string{
+		"test_constraints-Lookup": "sub.ID",
+		"test_constraints.Stats":  "Number",
+	}, uses)
+
+	// without type information, a defined type other than an interface looks like an embedded interface
+	require.Equal(t, []string{"Celsius"}, children["test_constraints.Temperature"])
+	require.Equal(t, "interface", kinds["test_constraints.Temperature"])
+	typeCheck = true
+	defer func() { typeCheck = false }()
+	review, err = createReview(filepath.Clean("testdata/test_constraints"))
+	require.NoError(t, err)
+	for _, n := range review.Navigation {
+		for _, item := range n.ChildItems {
+			kinds[item.NavigationID] = (*item.Tags)["TypeKind"]
+		}
+	}
+	require.Equal(t, "constraint", kinds["test_constraints.Temperature"])
+	require.Equal(t, "constraint", kinds["test_constraints.Key"])
+	require.Equal(t, "interface", kinds["test_constraints.Stringer"])
+}
+
+func TestTypeDefinitions(t *testing.T) {
+	for _, tc := range []bool{false, true} {
+		typeCheck = tc
+		review, err := createReview(filepath.Clean("testdata/test_typedefs"))
+		require.NoError(t, err)
+		type line struct {
+			links []string
+			text  string
+		}
+		actual := map[string]line{}
+		forAll(review.ReviewLines, func(rl ReviewLine) {
+			if rl.LineID == "" {
+				return
+			}
+			l := line{text: lineText(rl, nil)}
+			for _, tk := range rl.Tokens {
+				if tk.NavigateToID != "" {
+					l.links = append(l.links, tk.NavigateToID)
+				}
+			}
+			actual[rl.LineID] = l
+		})
+		for id, expected := range map[string]line{
+			"test_typedefs.EventPtr": {text: "type EventPtr *Event", links: []string{"test_typedefs.Event"}},
+			"test_typedefs.Events":   {text: "type Events <-chan Event", links: []string{"test_typedefs.Event"}},
+			// parentheses around a definition don't change the type
+			"test_typedefs.Handler": {text: "type Handler func(Event) error", links: []string{"test_typedefs.Event"}},
+			"test_typedefs.Headers": {text: "type Headers map[string][]string"},
+			// aliases of definitions in an internal package
+			"test_typedefs.Labels": {text: "type Labels map[string]string"},
+			"test_typedefs.Queue":  {text: "type Queue chan Item"},
+			// parenthesized structs and interfaces, and aliases of them
+			"test_typedefs.Settings":         {text: "type Settings struct"},
+			"test_typedefs.Settings-Verbose": {text: "Verbose bool"},
+			"test_typedefs.Sink":             {text: "type Sink interface"},
+			"test_typedefs.Sink-Write":       {text: "Write(e Event) error", links: []string{"test_typedefs.Event"}},
+			"test_typedefs.Options":          {text: "type Options struct"},
+			"test_typedefs.Options-Retries":  {text: "Retries int"},
+			"test_typedefs.Source":           {text: "type Source interface"},
+			"test_typedefs.Source-Read":      {text: "Read() (Item, error)"},
+			// methods of each type
+			"test_typedefs-(e Events) Next": {text: "func (Events) Next() Event", links: []string{"test_typedefs.Events", "test_typedefs.Event"}},
+			"test_typedefs-(h Headers) Get": {text: "func (Headers) Get(key string) string", links: []string{"test_typedefs.Headers"}},
+			"test_typedefs-(l Labels) Has":  {text: "func (Labels) Has(key string) bool", links: []string{"test_typedefs.Labels"}},
+			"test_typedefs-(q Queue) Len":   {text: "func (Queue) Len() int", links: []string{"test_typedefs.Queue"}},
+		} {
+			require.Contains(t, actual, id)
+			require.Equal(t, expected, actual[id], id)
+		}
+		for _, d := range review.Diagnostics {
+			require.NotContains(t, d.Text, unhandledTypeDefinition)
+		}
+	}
+	typeCheck = false
+}
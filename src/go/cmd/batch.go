@@ -0,0 +1,187 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// batchCmd represents the batch command
+var batchCmd = &cobra.Command{
+	Use:   "batch <repoRoot> <outputDir>",
+	Short: "Write reviews of every module in a repository",
+	Long: `batch finds every module under <repoRoot>, skipping hidden directories and testdata, and writes
+their reviews to <outputDir>, mirroring the modules' directories. For example, it writes the review
+of <repoRoot>/sdk/azcore to <outputDir>/sdk/azcore/azcore.json. The reviews share an index of
+modules, so batch indexes modules exporting types to other modules, such as azcore, only once.
+
+batch also writes <outputDir>/manifest.json, which lists each module's review, its diagnostics
+and any error. It reviews every module even when some fail, then exits with code 3 when any
+module couldn't be reviewed.`,
+	Args: usageArgs(cobra.ExactArgs(2)),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateFormat(); err != nil {
+			return err
+		}
+		if err := validateModuleDir(args[0]); err != nil {
+			return err
+		}
+		return validateModuleDir(args[1])
+	},
+	RunE: runBatch,
+}
+
+// batchManifest summarizes the reviews batchCmd writes
+type batchManifest struct {
+	Reviews []batchReview `json:"reviews"`
+}
+
+// batchReview describes the review of one module in a batchManifest
+type batchReview struct {
+	// Dir is the module's directory relative to the repository root e.g. "sdk/azcore"
+	Dir string `json:"dir"`
+	// Module is the module's path e.g. "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	Module string `json:"module,omitempty"`
+	// Output is the path of the review relative to the output directory e.g. "sdk/azcore/azcore.json"
+	Output         string `json:"output,omitempty"`
+	PackageName    string `json:"packageName,omitempty"`
+	PackageVersion string `json:"packageVersion,omitempty"`
+	// Diagnostics counts the review's diagnostics by level e.g. {"Warning": 2}
+	Diagnostics map[string]int `json:"diagnostics,omitempty"`
+	// Error explains why the module couldn't be reviewed
+	Error string `json:"error,omitempty"`
+}
+
+func init() {
+	batchCmd.Flags().BoolVar(&compact, "compact", false, "write reviews and the manifest without indentation")
+	batchCmd.Flags().StringVar(&format, "format", "json", `format of the reviews: "json" for APIView, "html" for standalone pages, or "markdown" or "text" for reading and diffing`)
+	batchCmd.Flags().BoolVar(&skipDiffTags, "skip-diff-tags", false, "exclude struct field tags from APIView's diffs of reviews")
+	rootCmd.AddCommand(batchCmd)
+}
+
+func runBatch(cmd *cobra.Command, args []string) error {
+	root, outDir := args[0], args[1]
+	dirs, err := findModules(root)
+	if err != nil {
+		return err
+	}
+	if len(dirs) == 0 {
+		return usageError(fmt.Errorf("found no modules under %s", root))
+	}
+	logger.Info("reviewing modules", "root", root, "modules", len(dirs))
+	index := newModuleIndex()
+	index.required = requiredModules(dirs)
+	manifest := batchManifest{Reviews: make([]batchReview, 0, len(dirs))}
+	failed, fatal := 0, 0
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(root, dir)
+		if err != nil {
+			return err
+		}
+		br := batchReview{Dir: filepath.ToSlash(rel)}
+		review, modPath, err := reviewModuleInIndex(index, dir, "")
+		if err != nil {
+			logger.Error("failed to review module", "dir", dir, "err", err)
+			br.Error = err.Error()
+			failed++
+			manifest.Reviews = append(manifest.Reviews, br)
+			continue
+		}
+		br.Module, br.PackageName, br.PackageVersion = modPath, review.PackageName, review.PackageVersion
+		br.Output = filepath.ToSlash(filepath.Join(rel, reviewFileName(review)))
+		for _, d := range review.Diagnostics {
+			if br.Diagnostics == nil {
+				br.Diagnostics = map[string]int{}
+			}
+			br.Diagnostics[d.Level.String()]++
+		}
+		if fatalDiagnosticsError(review, CodeDiagnosticLevelFatal) != nil {
+			fatal++
+		}
+		dest := filepath.Join(outDir, filepath.FromSlash(br.Output))
+		if err = os.MkdirAll(filepath.Dir(dest), 0755); err == nil {
+			err = writeReviewFile(dest, review)
+		}
+		if err != nil {
+			return err
+		}
+		logger.Info("wrote review", "path", dest)
+		manifest.Reviews = append(manifest.Reviews, br)
+	}
+	p := filepath.Join(outDir, "manifest.json")
+	if err = writeManifest(p, manifest); err != nil {
+		return err
+	}
+	logger.Info("wrote manifest", "path", p, "reviews", len(dirs)-failed, "failures", failed)
+	if failed > 0 {
+		return parseError(fmt.Errorf("failed to review %d of %d modules; see %s", failed, len(dirs), p))
+	}
+	if fatal > 0 {
+		return &codeError{code: exitFatal, err: fmt.Errorf("%d review(s) have fatal diagnostics; see %s", fatal, p)}
+	}
+	return nil
+}
+
+// findModules returns the directories of the modules under root, in lexical order. Like the go command,
+// it ignores directories whose names begin with "." or "_", and like NewModule it skips testdata.
+func findModules(root string) ([]string, error) {
+	dirs := []string{}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if p != root {
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			if strings.HasPrefix(d.Name(), ".") || strings.HasPrefix(d.Name(), "_") || skipTestdata(rel) {
+				return filepath.SkipDir
+			}
+		}
+		if isModuleRoot(p) {
+			dirs = append(dirs, p)
+		}
+		return nil
+	})
+	return dirs, err
+}
+
+// requiredModules returns the paths of the modules that the modules in dirs require. It ignores go.mod
+// files it can't parse, whose modules fail to review anyway.
+func requiredModules(dirs []string) map[string]bool {
+	required := map[string]bool{}
+	for _, dir := range dirs {
+		mf, err := parseModFile(dir)
+		if err != nil {
+			continue
+		}
+		for _, r := range mf.Require {
+			required[r.Mod.Path] = true
+		}
+	}
+	return required
+}
+
+// writeManifest writes manifest to the file at path as JSON, indented unless --compact is set
+func writeManifest(path string, manifest batchManifest) error {
+	var b []byte
+	var err error
+	if compact {
+		b, err = json.Marshal(manifest)
+	} else {
+		b, err = json.MarshalIndent(manifest, "", " ")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	return os.WriteFile(path, b, 0644)
+}
@@ -0,0 +1,75 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatch(t *testing.T) {
+	root := filepath.Join("testdata", "test_batch")
+	out := t.TempDir()
+	code, _, stderr := run(t, "batch", root, out)
+	// sdk/broken has an invalid go.mod
+	require.Equal(t, exitParse, code, stderr)
+
+	b, err := os.ReadFile(filepath.Join(out, "manifest.json"))
+	require.NoError(t, err)
+	manifest := batchManifest{}
+	require.NoError(t, json.Unmarshal(b, &manifest))
+	// batch skips .hidden
+	require.Len(t, manifest.Reviews, 3)
+	require.Equal(t, "sdk/broken", manifest.Reviews[0].Dir)
+	require.NotEmpty(t, manifest.Reviews[0].Error)
+	require.Empty(t, manifest.Reviews[0].Output)
+	core := "github.com/Azure/azure-sdk-tools/src/go/cmd/testdata/test_batch/sdk/core"
+	require.Equal(t, batchReview{Dir: "sdk/core", Module: core, Output: "sdk/core/core.json", PackageName: core}, manifest.Reviews[1])
+	require.Equal(t, "sdk/service/service.json", manifest.Reviews[2].Output)
+	require.Equal(t, map[string]int{"Warning": 1}, manifest.Reviews[2].Diagnostics)
+
+	// sdk/service exports a type defined in sdk/core, which batch indexes once for both reviews
+	indexed := 0
+	for _, ln := range strings.Split(stderr, "\n") {
+		if strings.Contains(ln, "indexing module") && strings.Contains(ln, filepath.Join("sdk", "core")) {
+			indexed++
+		}
+	}
+	require.Equal(t, 1, indexed, stderr)
+
+	// sharing the index doesn't affect reviews, e.g. reviewing sdk/core doesn't remove the methods sdk/service hoists
+	b, err = os.ReadFile(filepath.Join(out, "sdk", "service", "service.json"))
+	require.NoError(t, err)
+	actual := CodeFile{}
+	require.NoError(t, json.Unmarshal(b, &actual))
+	code, stdout, stderr := run(t, "generate", filepath.Join(root, "sdk", "service"), "-o", "-", "-q")
+	require.Zero(t, code, stderr)
+	expected := CodeFile{}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &expected))
+	require.Equal(t, expected, actual)
+
+	code, _, _ = run(t, "batch", filepath.Join(root, "sdk", "core", "go.mod"), out)
+	require.Equal(t, exitUsage, code)
+}
+
+func TestBatchIndexRetention(t *testing.T) {
+	root := filepath.Join("testdata", "test_batch", "sdk")
+	index := newModuleIndex()
+	index.required = requiredModules([]string{filepath.Join(root, "broken"), filepath.Join(root, "core"), filepath.Join(root, "service")})
+	for _, name := range []string{"core", "service"} {
+		_, _, err := reviewModuleInIndex(index, filepath.Join(root, name), "")
+		require.NoError(t, err)
+	}
+	// the index keeps core, which service requires, but not service, which no module requires
+	kept := []string{}
+	for dir := range index.local {
+		kept = append(kept, filepath.Base(dir))
+	}
+	require.Equal(t, []string{"core"}, kept)
+}
@@ -0,0 +1,355 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// useCache keeps downloaded modules in the apiviewgo cache, so later runs don't download them again
+var useCache bool
+
+// cacheDir is the apiviewgo cache's directory. When empty, the cache is in the user's cache directory.
+var cacheDir string
+
+const (
+	// lockPollInterval is how often moduleCache.lock checks whether another process released a lock
+	lockPollInterval = 100 * time.Millisecond
+	// staleLockAge is the age after which moduleCache.lock assumes a lock's holder crashed. It's much
+	// longer than GetExternalModule's download timeout, so no live process holds a lock this long.
+	staleLockAge = 10 * time.Minute
+)
+
+// moduleCache is a directory of modules downloaded by apiviewgo. It looks like:
+//
+//	~/.cache/apiviewgo
+//	├── lock
+//	│   └── github.com/!azure/azure-sdk-for-go/sdk
+//	│       └── azcore@v1.0.0.lock
+//	├── mod
+//	│   └── github.com/!azure/azure-sdk-for-go/sdk
+//	│       ├── azcore@v1.0.0
+//	│       │   └── go.mod
+//	│       └── azcore@v1.0.0.ziphash
+//	└── tmp
+//
+// Several apiviewgo processes may share a cache. A process holds a module's lock file while reading,
+// downloading or removing the module. Downloads go to tmp and are then renamed into mod, so mod
+// never contains partial downloads, even when a process crashes. As in the go command's cache, a
+// .ziphash file holds the verified h1: hash of a module's zip. It's written last, so a module without
+// one is incomplete.
+type moduleCache struct {
+	root string
+}
+
+// cachedModuleVersion is a module in the apiviewgo cache
+type cachedModuleVersion struct {
+	mod  module.Version
+	dir  string
+	size int64
+}
+
+// openModuleCache returns the cache in cacheDir or, when that's empty, the user's cache directory
+func openModuleCache() (moduleCache, error) {
+	root := cacheDir
+	if root == "" {
+		d, err := os.UserCacheDir()
+		if err != nil {
+			return moduleCache{}, fmt.Errorf("can't find a cache directory, set --cache-dir: %w", err)
+		}
+		root = filepath.Join(d, "apiviewgo")
+	}
+	for _, d := range []string{"lock", "mod", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(root, d), 0700); err != nil {
+			return moduleCache{}, fmt.Errorf("failed to create cache directory: %w", err)
+		}
+	}
+	return moduleCache{root: root}, nil
+}
+
+// escapedModuleVersion returns mod as a relative file path e.g. "github.com/!azure/azure-sdk-for-go/sdk/azcore@v1.0.0"
+func escapedModuleVersion(mod module.Version) (string, error) {
+	p, err := module.EscapePath(mod.Path)
+	if err != nil {
+		return "", fmt.Errorf("unescapeable module path %q: %w", mod.Path, err)
+	}
+	v, err := module.EscapeVersion(mod.Version)
+	if err != nil {
+		return "", fmt.Errorf("unescapeable module version %q: %w", mod.Version, err)
+	}
+	return filepath.FromSlash(p + "@" + v), nil
+}
+
+// get returns a Module representing mod, downloading mod to the cache when it isn't there. Because
+// sums may differ from those of the run that cached mod, get verifies cached modules' hashes as
+// verifyModuleHash would a download's.
+func (c moduleCache) get(ctx context.Context, mod module.Version, sums goSum) (*Module, error) {
+	modver, err := escapedModuleVersion(mod)
+	if err != nil {
+		return nil, err
+	}
+	unlock, err := c.lock(ctx, modver)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	d := filepath.Join(c.root, "mod", modver)
+	zh := d + ".ziphash"
+	if b, err := os.ReadFile(zh); err == nil {
+		if _, err = os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			env := goEnv("GONOSUMDB", "GOPRIVATE", "GOSUMDB")
+			if err = verifyModuleHash(ctx, mod, strings.TrimSpace(string(b)), sums, env); err != nil {
+				return nil, fmt.Errorf("failed to verify cached module %s, remove it with \"apiviewgo cache clean %s\": %w", mod, mod, err)
+			}
+			logger.Debug("using cached module", "module", mod, "dir", d)
+			return NewModule(d)
+		}
+	}
+	tmp, err := os.MkdirTemp(filepath.Join(c.root, "tmp"), "download")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create download directory: %w", err)
+	}
+	defer removeDownloadDir(tmp)
+	dest := filepath.Join(tmp, filepath.Base(d))
+	src, h, err := downloadModule(ctx, mod, sums, dest)
+	if err != nil {
+		return nil, err
+	}
+	if src != dest {
+		// the go command downloaded mod directly to the Go module cache
+		return NewModule(src)
+	}
+	// d may have content left by an older version of apiviewgo, a crash or someone tinkering with the cache
+	if err = removeCachedModule(d); err == nil {
+		if err = os.MkdirAll(filepath.Dir(d), 0700); err == nil {
+			if err = os.Rename(dest, d); err == nil {
+				err = os.WriteFile(zh, []byte(h+"\n"), 0600)
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to add %s to the cache: %w", mod, err)
+	}
+	logger.Debug("cached module", "module", mod, "dir", d)
+	return NewModule(d)
+}
+
+// lock acquires the lock file for modver, a module version escaped by escapedModuleVersion, waiting
+// until ctx is done for any other process holding it. It returns a func releasing the lock.
+func (c moduleCache) lock(ctx context.Context, modver string) (func(), error) {
+	p := filepath.Join(c.root, "lock", modver+".lock")
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	for {
+		f, err := os.OpenFile(p, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			// the pid helps people find the holder of a lock that's never released
+			fmt.Fprintln(f, os.Getpid())
+			if err = f.Close(); err != nil {
+				return nil, fmt.Errorf("failed to write %s: %w", p, err)
+			}
+			return func() {
+				if err := os.Remove(p); err != nil {
+					logger.Warn("failed to release cache lock", "lock", p, "err", err)
+				}
+			}, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("failed to create %s: %w", p, err)
+		}
+		if fi, err := os.Stat(p); err == nil && time.Since(fi.ModTime()) > staleLockAge {
+			logger.Warn("removing stale cache lock", "lock", p, "age", time.Since(fi.ModTime()).Round(time.Second))
+			if err := removeStaleLock(p, fi); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("gave up waiting for cache lock %s: %w", p, ctx.Err())
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// removeStaleLock removes the lock file p, which had file info fi when its holder was found to be gone.
+// Other waiters may find the same stale lock, and one of them may remove it and acquire a new lock before
+// this waiter acts. So removeStaleLock first moves p to a unique name and removes the moved file only when
+// it's the stale lock, restoring any new lock it moved.
+func removeStaleLock(p string, fi fs.FileInfo) error {
+	moved := fmt.Sprintf("%s.%d.%d.stale", p, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(p, moved); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// another waiter removed the lock
+			return nil
+		}
+		return fmt.Errorf("failed to remove stale lock %s: %w", p, err)
+	}
+	// file systems may reuse the stale lock's inode for a new lock, so compare modification times as well
+	if mfi, err := os.Stat(moved); err == nil && (!os.SameFile(fi, mfi) || !mfi.ModTime().Equal(fi.ModTime())) {
+		// Link, unlike Rename, fails instead of replacing a lock someone acquired meanwhile
+		if err = os.Link(moved, p); err != nil {
+			logger.Warn("failed to restore cache lock", "lock", p, "err", err)
+		}
+	}
+	if err := os.Remove(moved); err != nil {
+		return fmt.Errorf("failed to remove stale lock %s: %w", moved, err)
+	}
+	return nil
+}
+
+// list returns the modules in the cache, sorted by path and version
+func (c moduleCache) list() ([]cachedModuleVersion, error) {
+	mods := []cachedModuleVersion{}
+	root := filepath.Join(c.root, "mod")
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || !strings.Contains(d.Name(), "@") {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		escPath, escVersion, _ := strings.Cut(filepath.ToSlash(rel), "@")
+		m := cachedModuleVersion{dir: p}
+		if m.mod.Path, err = module.UnescapePath(escPath); err == nil {
+			m.mod.Version, err = module.UnescapeVersion(escVersion)
+		}
+		if err != nil {
+			logger.Warn("ignoring unexpected directory in cache", "dir", p)
+			return filepath.SkipDir
+		}
+		err = filepath.WalkDir(p, func(_ string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				var fi fs.FileInfo
+				if fi, err = d.Info(); err == nil {
+					m.size += fi.Size()
+				}
+			}
+			return err
+		})
+		mods = append(mods, m)
+		if err == nil {
+			err = filepath.SkipDir
+		}
+		return err
+	})
+	sort.Slice(mods, func(i, j int) bool {
+		if mods[i].mod.Path != mods[j].mod.Path {
+			return mods[i].mod.Path < mods[j].mod.Path
+		}
+		return semver.Compare(mods[i].mod.Version, mods[j].mod.Version) < 0
+	})
+	return mods, err
+}
+
+// remove removes m from the cache, waiting until ctx is done for any process using it
+func (c moduleCache) remove(ctx context.Context, m cachedModuleVersion) error {
+	modver, err := escapedModuleVersion(m.mod)
+	if err != nil {
+		return err
+	}
+	unlock, err := c.lock(ctx, modver)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err = removeCachedModule(m.dir); err != nil {
+		return fmt.Errorf("failed to remove %s from the cache: %w", m.mod, err)
+	}
+	return nil
+}
+
+// removeCachedModule removes the cached module in d and its .ziphash file
+func removeCachedModule(d string) error {
+	if err := os.Remove(d + ".ziphash"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return os.RemoveAll(d)
+}
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of downloaded modules",
+	Long: `apiviewgo downloads modules defining types the reviewed module exports. With --cache, it keeps
+these modules in a cache shared by later runs, in the user's cache directory or --cache-dir.`,
+	Args: usageArgs(cobra.NoArgs),
+}
+
+// cacheListCmd represents the cache list command
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List cached modules",
+	Args:  usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := openModuleCache()
+		if err != nil {
+			return err
+		}
+		mods, err := c.list()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "Cache:\t%s\n\n", c.root)
+		fmt.Fprintln(w, "MODULE\tVERSION\tBYTES")
+		for _, m := range mods {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", m.mod.Path, m.mod.Version, m.size)
+		}
+		return w.Flush()
+	},
+}
+
+// cacheCleanCmd represents the cache clean command
+var cacheCleanCmd = &cobra.Command{
+	Use:   "clean [module[@version]...]",
+	Short: "Remove cached modules",
+	Long: `clean removes the given modules from the cache, or all modules when none are given. A module
+without a version matches all its cached versions.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := openModuleCache()
+		if err != nil {
+			return err
+		}
+		mods, err := c.list()
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(cmd.Context(), time.Minute)
+		defer cancel()
+		n := 0
+		for _, m := range mods {
+			if len(args) > 0 && !slices.ContainsFunc(args, func(a string) bool { return a == m.mod.Path || a == m.mod.String() }) {
+				continue
+			}
+			if err = c.remove(ctx, m); err != nil {
+				return err
+			}
+			n++
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Removed %d cached modules\n", n)
+		return nil
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheListCmd, cacheCleanCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
@@ -0,0 +1,145 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/mod/module"
+)
+
+func TestModuleCache(t *testing.T) {
+	mod := module.Version{Path: "example.com/Vars", Version: "v1.0.0"}
+	proxy, h := fileProxy(t, mod, filepath.Join("testdata", "test_vars"))
+	t.Setenv("GOFLAGS", "")
+	t.Setenv("GONOPROXY", "")
+	t.Setenv("GOPRIVATE", "")
+	t.Setenv("GOPROXY", proxy)
+	t.Setenv("GOSUMDB", "off")
+	// run resets cacheDir after executing each command
+	dir := t.TempDir()
+	cacheDir = dir
+	defer func() { cacheDir = "" }()
+	c, err := openModuleCache()
+	require.NoError(t, err)
+
+	// concurrent gets wait for each other and all succeed
+	wg := sync.WaitGroup{}
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = c.get(context.Background(), mod, nil)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	require.FileExists(t, filepath.Join(dir, "mod", "example.com", "!vars@v1.0.0", "go.mod"))
+
+	// later gets don't download the module
+	t.Setenv("GOPROXY", "off")
+	m, err := c.get(context.Background(), mod, nil)
+	require.NoError(t, err)
+	require.Contains(t, m.Packages, "test_vars")
+
+	// cache hits are verified against go.sum
+	zh := filepath.Join(dir, "mod", "example.com", "!vars@v1.0.0.ziphash")
+	b, err := os.ReadFile(zh)
+	require.NoError(t, err)
+	require.Equal(t, h+"\n", string(b))
+	_, err = c.get(context.Background(), mod, goSum{mod: h})
+	require.NoError(t, err)
+	_, err = c.get(context.Background(), mod, goSum{mod: "h1:bad"})
+	require.ErrorIs(t, err, errChecksumMismatch)
+
+	// a module without a .ziphash is incomplete, so get downloads it again
+	require.NoError(t, os.Remove(zh))
+	_, err = c.get(context.Background(), mod, nil)
+	require.ErrorContains(t, err, "GOPROXY=off")
+	t.Setenv("GOPROXY", proxy)
+	_, err = c.get(context.Background(), mod, goSum{mod: h})
+	require.NoError(t, err)
+	require.FileExists(t, zh)
+
+	t.Run("lock", func(t *testing.T) {
+		modver, err := escapedModuleVersion(mod)
+		require.NoError(t, err)
+		_, err = c.lock(context.Background(), modver)
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 3*lockPollInterval)
+		defer cancel()
+		_, err = c.get(ctx, mod, nil)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		// a lock older than staleLockAge doesn't block other processes
+		lock := filepath.Join(dir, "lock", modver+".lock")
+		old := time.Now().Add(-2 * staleLockAge)
+		require.NoError(t, os.Chtimes(lock, old, old))
+		_, err = c.get(context.Background(), mod, nil)
+		require.NoError(t, err)
+		require.NoFileExists(t, lock)
+
+		// a waiter finding a stale lock another waiter already replaced leaves the new lock in place
+		require.NoError(t, os.WriteFile(lock, nil, 0600))
+		require.NoError(t, os.Chtimes(lock, old, old))
+		stale, err := os.Stat(lock)
+		require.NoError(t, err)
+		require.NoError(t, os.Remove(lock))
+		require.NoError(t, os.WriteFile(lock, []byte("new"), 0600))
+		require.NoError(t, removeStaleLock(lock, stale))
+		b, err := os.ReadFile(lock)
+		require.NoError(t, err)
+		require.Equal(t, "new", string(b))
+		require.NoError(t, removeStaleLock(lock, stale))
+		require.FileExists(t, lock)
+		entries, err := os.ReadDir(filepath.Dir(lock))
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.NoError(t, os.Remove(lock))
+	})
+
+	t.Run("list", func(t *testing.T) {
+		// versions sort by semantic version rather than text
+		for _, v := range []string{"v1.10.0", "v1.9.0"} {
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, "mod", "example.com", "sorted@"+v), 0700))
+		}
+		mods, err := c.list()
+		require.NoError(t, err)
+		versions := []string{}
+		for _, m := range mods {
+			if m.mod.Path == "example.com/sorted" {
+				versions = append(versions, m.mod.Version)
+			}
+		}
+		require.Equal(t, []string{"v1.9.0", "v1.10.0"}, versions)
+	})
+
+	t.Run("commands", func(t *testing.T) {
+		code, stdout, stderr := run(t, "cache", "list", "--cache-dir", dir)
+		require.Zero(t, code, stderr)
+		require.Regexp(t, `example.com/Vars\s+v1.0.0\s+\d+`, stdout)
+
+		code, stdout, stderr = run(t, "cache", "clean", "example.com/other", "--cache-dir", dir)
+		require.Zero(t, code, stderr)
+		require.Equal(t, "Removed 0 cached modules\n", stdout)
+
+		code, stdout, stderr = run(t, "cache", "clean", "example.com/Vars@v1.0.0", "--cache-dir", dir)
+		require.Zero(t, code, stderr)
+		require.Equal(t, "Removed 1 cached modules\n", stdout)
+
+		code, stdout, _ = run(t, "cache", "list", "--cache-dir", dir)
+		require.Zero(t, code)
+		require.NotContains(t, stdout, "example.com/Vars")
+		require.NoFileExists(t, filepath.Join(dir, "mod", "example.com", "!vars@v1.0.0.ziphash"))
+	})
+}
@@ -0,0 +1,215 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/dirhash"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// sumGolangOrgKey is the verifier key of sum.golang.org, the go command's default checksum database
+const sumGolangOrgKey = "sum.golang.org+033de0ae+Ac4zctda0e5eza+HJyk9SxEdh+s3Ey18htWdiinA3nAo"
+
+// errChecksumMismatch indicates a downloaded module's hash doesn't match the expected hash. Unlike
+// other download errors, it stops downloadModule from trying other proxies.
+var errChecksumMismatch = errors.New("checksum mismatch")
+
+// goSum maps module versions to the h1: hashes of their zips, as recorded in a go.sum file.
+// It omits go.mod hashes because apiviewgo verifies only zips.
+type goSum map[module.Version]string
+
+// readGoSum reads the go.sum file in dir. It returns an empty goSum when there's no such file.
+func readGoSum(dir string) (goSum, error) {
+	sums := goSum{}
+	p := filepath.Join(dir, "go.sum")
+	b, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return sums, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", p, err)
+	}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; s.Scan(); n++ {
+		f := strings.Fields(s.Text())
+		switch {
+		case len(f) == 0:
+			continue
+		case len(f) != 3:
+			return nil, fmt.Errorf("%s:%d: malformed line %q", p, n, s.Text())
+		case strings.HasSuffix(f[1], "/go.mod"):
+			continue
+		}
+		sums[module.Version{Path: f[0], Version: f[1]}] = f[2]
+	}
+	return sums, s.Err()
+}
+
+// verifyModuleZip checks the hash of the zip of mod at zp against the reviewed module's go.sum or,
+// when go.sum has no entry for mod, the checksum database named by GOSUMDB, as the go command would.
+// It skips the checksum database when GOSUMDB is "off" or mod matches GONOSUMDB (by default, GOPRIVATE).
+// Errors wrap errChecksumMismatch when the hashes differ. verifyModuleZip returns the verified hash.
+func verifyModuleZip(ctx context.Context, mod module.Version, zp string, sums goSum, env map[string]string) (string, error) {
+	h, err := dirhash.HashZip(zp, dirhash.Hash1)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", zp, err)
+	}
+	return h, verifyModuleHash(ctx, mod, h, sums, env)
+}
+
+// verifyModuleHash checks h, the h1: hash of mod's zip, as described for verifyModuleZip
+func verifyModuleHash(ctx context.Context, mod module.Version, h string, sums goSum, env map[string]string) error {
+	if want, ok := sums[mod]; ok {
+		if h != want {
+			return fmt.Errorf("%w for %s: downloaded %s but go.sum has %s", errChecksumMismatch, mod, h, want)
+		}
+		return nil
+	}
+	noSumDB := env["GONOSUMDB"]
+	if noSumDB == "" {
+		noSumDB = env["GOPRIVATE"]
+	}
+	if env["GOSUMDB"] == "off" || module.MatchPrefixPatterns(noSumDB, mod.Path) {
+		logger.Warn("can't verify module because go.sum has no entry for it and GOSUMDB doesn't apply", "module", mod)
+		return nil
+	}
+	db, err := newSumDBOps(ctx, env["GOSUMDB"])
+	if err != nil {
+		return err
+	}
+	lines, err := sumdb.NewClient(db).Lookup(mod.Path, mod.Version)
+	if err != nil {
+		return fmt.Errorf("failed to look up %s in checksum database %s: %w", mod, db.url, err)
+	}
+	for _, ln := range lines {
+		if f := strings.Fields(ln); len(f) == 3 && f[0] == mod.Path && f[1] == mod.Version {
+			if h != f[2] {
+				return fmt.Errorf("%w for %s: downloaded %s but checksum database %s has %s", errChecksumMismatch, mod, h, db.url, f[2])
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("checksum database %s has no hash for %s", db.url, mod)
+}
+
+// sumDBOps implements sumdb.ClientOps for a single apiviewgo run. It keeps the database's
+// signed tree and tiles in memory, so every run verifies lookups from scratch.
+type sumDBOps struct {
+	ctx context.Context
+	// key is the database's verifier key
+	key string
+	// url is the database's URL e.g. "https://sum.golang.org"
+	url string
+
+	mu     sync.Mutex
+	config map[string][]byte
+	cache  map[string][]byte
+}
+
+// newSumDBOps returns ClientOps for the checksum database described by gosumdb, a value of GOSUMDB such
+// as "sum.golang.org" or "name+key https://sumdb.example.com". An empty gosumdb means sum.golang.org.
+func newSumDBOps(ctx context.Context, gosumdb string) (*sumDBOps, error) {
+	f := strings.Fields(gosumdb)
+	switch {
+	case len(f) == 0:
+		f = []string{"sum.golang.org"}
+	case len(f) > 2:
+		return nil, fmt.Errorf("invalid GOSUMDB %q: too many fields", gosumdb)
+	}
+	db := sumDBOps{ctx: ctx, key: f[0], config: map[string][]byte{}, cache: map[string][]byte{}}
+	switch db.key {
+	case "sum.golang.org":
+		db.key = sumGolangOrgKey
+	case "sum.golang.google.cn":
+		// a mirror of sum.golang.org, as the go command knows it
+		db.key, db.url = sumGolangOrgKey, "https://sum.golang.google.cn"
+	}
+	v, err := note.NewVerifier(db.key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GOSUMDB %q: %w", gosumdb, err)
+	}
+	if len(f) == 2 {
+		db.url = f[1]
+	} else if db.url == "" {
+		db.url = "https://" + v.Name()
+	}
+	db.url = strings.TrimSuffix(db.url, "/")
+	return &db, nil
+}
+
+func (db *sumDBOps) ReadRemote(path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(db.ctx, http.MethodGet, db.url+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := proxyClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err == nil && resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("checksum database responded %d: %s", resp.StatusCode, b)
+	}
+	return b, err
+}
+
+func (db *sumDBOps) ReadConfig(file string) ([]byte, error) {
+	if file == "key" {
+		return []byte(db.key), nil
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	// a nil result for "{name}/latest" means the client starts with an empty tree
+	return db.config[file], nil
+}
+
+func (db *sumDBOps) WriteConfig(file string, old, new []byte) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if !bytes.Equal(db.config[file], old) {
+		return sumdb.ErrWriteConflict
+	}
+	db.config[file] = new
+	return nil
+}
+
+func (db *sumDBOps) ReadCache(file string) ([]byte, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if b, ok := db.cache[file]; ok {
+		return b, nil
+	}
+	return nil, fs.ErrNotExist
+}
+
+func (db *sumDBOps) WriteCache(file string, data []byte) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.cache[file] = data
+}
+
+func (db *sumDBOps) Log(msg string) {
+	logger.Debug(msg, "sumdb", db.url)
+}
+
+func (db *sumDBOps) SecurityError(msg string) {
+	// the client returns sumdb.ErrSecurity, which fails the download
+	logger.Error(msg, "sumdb", db.url)
+}
@@ -0,0 +1,281 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// reasons an API change is or isn't breaking
+const (
+	reasonAdded            = "added"
+	reasonMethodAdded      = "method added to an interface applications can implement"
+	reasonNamesChanged     = "parameter names changed"
+	reasonRemoved          = "removed"
+	reasonSignatureChanged = "signature changed"
+	reasonTypeChanged      = "type changed"
+	reasonValueChanged     = "literal value changed"
+)
+
+// breakingWithoutMajorBump is the diagnostic message for a breaking change in a version having the same major version
+const breakingWithoutMajorBump = "Breaking change requires a new major version: "
+
+// declaration describes a line of a review for the purpose of classifying changes to it
+type declaration struct {
+	// lineID is the LineID of the declaration's line
+	lineID string
+	// kind is the declaration's keyword e.g. "func" or "type", or "field" or "method" for
+	// struct fields and interface methods
+	kind string
+	// parent is the LineID of the type declaring a field or method
+	parent string
+	// sig is the declaration's text excluding literal values such as the value of a const, and the
+	// names of parameters and results, which don't affect compatibility
+	sig string
+	// text is the declaration's text
+	text string
+}
+
+// declarations maps the LineID of each line in lines, and their children, to a description of the
+// line. Line text excludes documentation and tokens APIView ignores when diffing.
+func declarations(lines []ReviewLine) map[string]declaration {
+	decls := map[string]declaration{}
+	var visit func(lines []ReviewLine, parent *ReviewLine, block string)
+	visit = func(lines []ReviewLine, parent *ReviewLine, block string) {
+		for i := range lines {
+			ln := &lines[i]
+			kw := ""
+			if len(ln.Tokens) > 0 && ln.Tokens[0].Kind == TokenKindKeyword {
+				kw = ln.Tokens[0].Value
+			}
+			if ln.LineID == "" {
+				if kw == "const" || kw == "var" {
+					// a block of const or var declarations
+					visit(ln.Children, parent, kw)
+				} else {
+					visit(ln.Children, parent, block)
+				}
+				continue
+			}
+			d := declaration{
+				kind:   kw,
+				lineID: ln.LineID,
+				sig: lineText(withoutParamNames(*ln), func(tk ReviewToken) bool {
+					return diffable(tk) && tk.Kind != TokenKindStringLiteral
+				}),
+				text: lineText(*ln, diffable),
+			}
+			switch {
+			case block != "" && kw == "":
+				d.kind = block
+			case kw == "" && parent != nil:
+				d.parent = parent.LineID
+				d.kind = "field"
+				if hasKeyword(*parent, "interface") {
+					d.kind = "method"
+				}
+			case kw == "func" && parent != nil:
+				d.parent = parent.LineID
+			}
+			decls[ln.LineID] = d
+			if kw == "type" {
+				visit(ln.Children, ln, "")
+			} else {
+				visit(ln.Children, parent, "")
+			}
+		}
+	}
+	visit(lines, nil, "")
+	return decls
+}
+
+// withoutParamNames returns ln without the names of parameters and results, which are the member
+// names following "(" or "," in the lines of funcs and interface methods
+func withoutParamNames(ln ReviewLine) ReviewLine {
+	tks := make([]ReviewToken, 0, len(ln.Tokens))
+	prev := ""
+	for _, tk := range ln.Tokens {
+		if strings.TrimSpace(tk.Value) == "" {
+			tks = append(tks, tk)
+			continue
+		}
+		if tk.Kind == TokenKindMemberName && (prev == "(" || prev == ",") {
+			prev = ""
+			continue
+		}
+		prev = tk.Value
+		tks = append(tks, tk)
+	}
+	ln.Tokens = tks
+	return ln
+}
+
+// diffKey identifies the declaration having the given LineID across versions of a module. It's the
+// LineID except for methods, whose LineIDs include the receiver's name e.g. "pkg-(c *Client) Get".
+// Renaming a receiver doesn't change the API, so a method's key has only its package, receiver base
+// type and name e.g. "pkg-(Client) Get".
+func diffKey(lineID string) string {
+	pkg, sig, found := strings.Cut(lineID, "-(")
+	if !found {
+		return lineID
+	}
+	recv, name, found := strings.Cut(sig, ") ")
+	if !found {
+		return lineID
+	}
+	base := recv[strings.LastIndex(recv, " ")+1:]
+	base = strings.TrimPrefix(base, "*")
+	if i := strings.Index(base, "["); i >= 0 {
+		base = base[:i]
+	}
+	return pkg + "-(" + base + ") " + name
+}
+
+// diffable returns true for tokens that should be compared when diffing reviews. It keeps whitespace
+// such as struct field alignment so that lineText can space the remaining tokens.
+func diffable(tk ReviewToken) bool {
+	return !tk.IsDocumentation && (!tk.SkipDiff || strings.TrimSpace(tk.Value) == "")
+}
+
+// hasKeyword returns true when ln has a keyword token having the given value
+func hasKeyword(ln ReviewLine, kw string) bool {
+	for _, tk := range ln.Tokens {
+		if tk.Kind == TokenKindKeyword && tk.Value == kw {
+			return true
+		}
+	}
+	return false
+}
+
+// classifyAdded returns whether adding d breaks compatibility, and why. Adding a declaration is
+// compatible unless it's a method of an existing interface applications can implement.
+func classifyAdded(d declaration, before map[string]declaration, sealed map[string]bool) (bool, string) {
+	if d.kind == "method" && !sealed[d.parent] {
+		if _, ok := before[d.parent]; ok {
+			return true, reasonMethodAdded
+		}
+	}
+	return false, reasonAdded
+}
+
+// classifyChanged returns whether changing a declaration from o to n breaks compatibility, and why
+func classifyChanged(o, n declaration) (bool, string) {
+	switch {
+	case o.sig == n.sig && (n.kind == "func" || n.kind == "method"):
+		// only the names of parameters or results changed
+		return false, reasonNamesChanged
+	case o.sig == n.sig:
+		// only a literal value changed e.g. "const Foo = 1" became "const Foo = 2"
+		return false, reasonValueChanged
+	case n.kind == "func" || n.kind == "method":
+		return true, reasonSignatureChanged
+	default:
+		return true, reasonTypeChanged
+	}
+}
+
+// sealedInterfaces returns the LineIDs of interfaces the given review reports as sealed
+func sealedInterfaces(cf CodeFile) map[string]bool {
+	sealed := map[string]bool{}
+	for _, d := range cf.Diagnostics {
+		if d.Text == sealedInterface {
+			sealed[d.TargetID] = true
+		}
+	}
+	return sealed
+}
+
+// majorVersion returns the major version implied by a module path's major version suffix
+// e.g. 2 for "github.com/Azure/azure-sdk-for-go/sdk/foo/v2". It returns 1 for paths having
+// no suffix because such a module's version may be v0 or v1.
+func majorVersion(modPath string) int {
+	if loc := versionReg.FindStringIndex(modPath); loc != nil && loc[1] == len(modPath) {
+		if v, err := strconv.Atoi(strings.Trim(modPath[loc[0]:], "/v")); err == nil {
+			return v
+		}
+	}
+	return 1
+}
+
+// baselineDiagnostics compares review, a review of the module at modPath, to a review of the module in
+// baselineDir, returning a fatal diagnostic for each breaking change when the reviewed module doesn't
+// have a new major version.
+func baselineDiagnostics(review CodeFile, modPath, baselineDir string) ([]CodeDiagnostic, error) {
+	oldFile, oldPath, err := reviewModule(baselineDir, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to review baseline module: %w", err)
+	}
+	d := diffCodeFiles(oldFile, review)
+	d.MajorVersionBump = majorVersionBump(oldPath, oldFile.PackageVersion, modPath, review.PackageVersion)
+	return breakingChangeDiagnostics(d, review), nil
+}
+
+// majorVersionBump returns true when a module's new version may break compatibility with its old version.
+// That's the case when the new module path has a greater major version suffix, or when the versions are
+// known and the new version has a greater major version or is an unstable v0 version.
+func majorVersionBump(oldPath, oldVersion, newPath, newVersion string) bool {
+	if majorVersion(newPath) > majorVersion(oldPath) {
+		return true
+	}
+	if !semver.IsValid(newVersion) {
+		return false
+	}
+	if semver.Major(newVersion) == "v0" {
+		return true
+	}
+	return semver.IsValid(oldVersion) && semver.Compare(semver.Major(newVersion), semver.Major(oldVersion)) > 0
+}
+
+// breakingChangeDiagnostics returns a fatal diagnostic for each breaking change in d when the new
+// version doesn't have a new major version. Each diagnostic targets the changed line in the new
+// review or, for removed declarations, the nearest line remaining from the removed declaration's
+// context e.g. the struct of a removed field.
+func breakingChangeDiagnostics(d APIDiff, newFile CodeFile) []CodeDiagnostic {
+	diags := []CodeDiagnostic{}
+	if d.MajorVersionBump {
+		return diags
+	}
+	after := declarations(newFile.ReviewLines)
+	for _, ld := range d.Breaking() {
+		target := ld.LineID
+		if _, ok := after[target]; !ok {
+			target = nearestTarget(ld.LineID, after, newFile)
+		}
+		diags = append(diags, CodeDiagnostic{
+			DiagnosticID: breakingChangeID,
+			Level:        CodeDiagnosticLevelFatal,
+			TargetID:     target,
+			Text:         fmt.Sprintf("%s%s (%s)", breakingWithoutMajorBump, ld.LineID, ld.Reason),
+		})
+	}
+	return diags
+}
+
+// nearestTarget returns the LineID of the line in newFile nearest the removed declaration
+// identified by id. That's the declaration's type or package when those remain, otherwise
+// the first package in newFile.
+func nearestTarget(id string, after map[string]declaration, newFile CodeFile) string {
+	// removed field or method IDs look like "pkg.Type-Name"; funcs look like "pkg-Name"
+	if before, _, found := strings.Cut(id, "-"); found {
+		if _, ok := after[before]; ok {
+			return before
+		}
+		id = before
+	}
+	if dot := strings.LastIndex(id, "."); dot > 0 {
+		if _, ok := after[id[:dot]]; ok {
+			return id[:dot]
+		}
+	}
+	for _, ln := range newFile.ReviewLines {
+		if ln.LineID != "" {
+			return ln.LineID
+		}
+	}
+	return ""
+}
@@ -0,0 +1,242 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileNames are the names of the files configuring lint rules. Review reads the one in the
+// root of the reviewed module.
+var configFileNames = []string{"apiviewgo.yaml", "apiviewgo.yml", "apiviewgo.json"}
+
+// config tunes the lint rules of a module's review
+type config struct {
+	// Rules maps rule IDs to their settings
+	Rules map[string]ruleSettings `json:"rules" yaml:"rules"`
+	// Suppressions omit particular diagnostics from the review
+	Suppressions []suppression `json:"suppressions" yaml:"suppressions"`
+
+	// path of the file declaring this config
+	path string
+}
+
+// ruleSettings configure a lint rule
+type ruleSettings struct {
+	// Disabled turns the rule off
+	Disabled bool `json:"disabled" yaml:"disabled"`
+	// Level overrides the level of the rule's diagnostics e.g. "error"
+	Level string `json:"level" yaml:"level"`
+}
+
+// suppression omits a rule's diagnostic for a line of the review
+type suppression struct {
+	// Rule is the ID of the suppressed rule. When empty, the suppression applies to all rules.
+	Rule string `json:"rule" yaml:"rule"`
+	// Target is the LineID of the line whose diagnostic to suppress e.g. "azblob-(c *Client) Upload"
+	Target string `json:"target" yaml:"target"`
+	// Justification explains why the line may violate the rule. It's required.
+	Justification string `json:"justification" yaml:"justification"`
+}
+
+// loadConfig returns the config in dir, or nil when dir has no config file
+func loadConfig(dir string) (*config, error) {
+	found := []string{}
+	for _, name := range configFileNames {
+		p := filepath.Join(dir, name)
+		if _, err := os.Stat(p); err == nil {
+			found = append(found, p)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, nil
+	case 1:
+	default:
+		return nil, fmt.Errorf("found multiple config files: %s", strings.Join(found, ", "))
+	}
+	b, err := os.ReadFile(found[0])
+	if err != nil {
+		return nil, err
+	}
+	cfg := &config{path: found[0]}
+	if filepath.Ext(found[0]) == ".json" {
+		d := json.NewDecoder(bytes.NewReader(b))
+		d.DisallowUnknownFields()
+		err = d.Decode(cfg)
+	} else {
+		d := yaml.NewDecoder(bytes.NewReader(b))
+		d.KnownFields(true)
+		// an empty file decodes to io.EOF
+		if err = d.Decode(cfg); errors.Is(err, io.EOF) {
+			err = nil
+		}
+	}
+	if err == nil {
+		err = cfg.validate()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", found[0], err)
+	}
+	logger.Debug("using config", "path", found[0])
+	return cfg, nil
+}
+
+// builtinDiagnosticIDs are the DiagnosticIDs of diagnostics reviews include regardless of lint rules
+var builtinDiagnosticIDs = []string{
+	aliasID,
+	breakingChangeID,
+	constraintOutsideTypeParID,
+	deprecatedID,
+	embedsUnexportedStructID,
+	exposesInternalTypeID,
+	missingAliasID,
+	parserWarningID,
+	platformDifferenceID,
+	platformOnlyID,
+	sealedInterfaceID,
+	unhandledTypeDefinitionID,
+	unusedSuppressionID,
+}
+
+// validate returns an error when cfg refers to unknown rules or levels, or lacks a justification
+func (cfg *config) validate() error {
+	for id, rs := range cfg.Rules {
+		if !isRule(id) {
+			return fmt.Errorf("unknown rule %q", id)
+		}
+		if rs.Level != "" {
+			if _, err := parseLevel(rs.Level); err != nil {
+				return fmt.Errorf("rule %q: %w", id, err)
+			}
+		}
+	}
+	for i, s := range cfg.Suppressions {
+		switch {
+		case s.Rule != "" && !isRule(s.Rule):
+			return fmt.Errorf("suppression %d: unknown rule %q", i+1, s.Rule)
+		case s.Target == "":
+			return fmt.Errorf("suppression %d: target is required", i+1)
+		case strings.TrimSpace(s.Justification) == "":
+			return fmt.Errorf("suppression %d: justification is required", i+1)
+		}
+	}
+	return nil
+}
+
+// isRule returns whether id identifies one of lintRules or a built-in diagnostic
+func isRule(id string) bool {
+	return isLintRule(id) || slices.Contains(builtinDiagnosticIDs, id)
+}
+
+// isLintRule returns whether id identifies one of lintRules
+func isLintRule(id string) bool {
+	for _, r := range lintRules {
+		if r.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// apply returns rules as configured by cfg, omitting disabled rules
+func (cfg *config) apply(rules []lintRule) []lintRule {
+	if cfg == nil {
+		return rules
+	}
+	configured := make([]lintRule, 0, len(rules))
+	for _, r := range rules {
+		rs := cfg.Rules[r.id]
+		if rs.Disabled {
+			continue
+		}
+		if rs.Level != "" {
+			// validate checked the level
+			r.level, _ = parseLevel(rs.Level)
+		}
+		configured = append(configured, r)
+	}
+	return configured
+}
+
+// configure returns diagnostics as configured by cfg. It suppresses diagnostics as described by suppress
+// and then applies the settings of rules and built-in diagnostics to the diagnostics having their IDs.
+func (cfg *config) configure(diagnostics []CodeDiagnostic, lines []ReviewLine) []CodeDiagnostic {
+	if cfg == nil {
+		return diagnostics
+	}
+	configured := []CodeDiagnostic{}
+	for _, d := range cfg.suppress(diagnostics, lines) {
+		rs := cfg.Rules[d.DiagnosticID]
+		if rs.Disabled {
+			continue
+		}
+		if rs.Level != "" {
+			// validate checked the level
+			d.Level, _ = parseLevel(rs.Level)
+		}
+		configured = append(configured, d)
+	}
+	return configured
+}
+
+// suppress returns diagnostics without those cfg suppresses, adding a warning for each suppression
+// matching no diagnostic. Such a warning targets the suppression's line or, when the review no longer
+// has that line, the nearest remaining one in lines.
+func (cfg *config) suppress(diagnostics []CodeDiagnostic, lines []ReviewLine) []CodeDiagnostic {
+	if cfg == nil {
+		return diagnostics
+	}
+	used := make([]bool, len(cfg.Suppressions))
+	kept := []CodeDiagnostic{}
+	for _, d := range diagnostics {
+		suppressed := false
+		for i, s := range cfg.Suppressions {
+			if s.Target == d.TargetID && (s.Rule == "" || s.Rule == d.DiagnosticID) {
+				suppressed, used[i] = true, true
+			}
+		}
+		if !suppressed {
+			kept = append(kept, d)
+		}
+	}
+	var decls map[string]declaration
+	for i, s := range cfg.Suppressions {
+		// --guideline-diagnostics=false omits the diagnostics of lint rules, so their suppressions may be unused
+		if used[i] || cfg.Rules[s.Rule].Disabled || (!guidelineDiagnostics && isLintRule(s.Rule)) {
+			continue
+		}
+		if decls == nil {
+			decls = declarations(lines)
+		}
+		target := s.Target
+		if _, ok := decls[target]; !ok {
+			target = nearestTarget(target, decls, CodeFile{ReviewLines: lines})
+		}
+		rule := s.Rule
+		if rule == "" {
+			rule = "any rule"
+		}
+		kept = append(kept, CodeDiagnostic{
+			DiagnosticID: unusedSuppressionID,
+			Level:        CodeDiagnosticLevelWarning,
+			TargetID:     target,
+			Text:         fmt.Sprintf("%s suppresses %s for %s, which has no such diagnostic", filepath.Base(cfg.path), rule, s.Target),
+		})
+	}
+	return kept
+}
@@ -0,0 +1,158 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig(t *testing.T) {
+	review, err := createReview(filepath.Join("testdata", "test_config"))
+	require.NoError(t, err)
+	require.Equal(t, []CodeDiagnostic{
+		{
+			// the removed method's package
+			DiagnosticID: unusedSuppressionID,
+			Level:        CodeDiagnosticLevelWarning,
+			TargetID:     "test_config",
+			Text:         "apiviewgo.yaml suppresses any rule for test_config-(c *Client) Get, which has no such diagnostic",
+		},
+		{
+			DiagnosticID: "client-method-context",
+			HelpLinkURI:  contextGuidelineURI,
+			Level:        CodeDiagnosticLevelError,
+			TargetID:     "test_config-(c *Client) Delete",
+			Text:         "Client.Delete should have a context.Context first parameter",
+		},
+		{
+			// the config changes the level of a built-in diagnostic
+			DiagnosticID: deprecatedID,
+			Level:        CodeDiagnosticLevelWarning,
+			TargetID:     "test_config-(c *Client) Put",
+			Text:         "Deprecated: use Update instead.",
+		},
+	}, review.Diagnostics)
+
+	t.Run("baseline", func(t *testing.T) {
+		// suppressions apply to breaking changes
+		dir := t.TempDir()
+		b, err := os.ReadFile(filepath.Join("testdata", "test_config", "test.go"))
+		require.NoError(t, err)
+		for name, content := range map[string]string{
+			"go.mod":  "module github.com/Azure/azure-sdk-tools/src/go/cmd/testdata/test_config\n\ngo 1.18\n",
+			"test.go": string(b) + "\nfunc Removed() {}\n",
+		} {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+		}
+		review := func(s ...suppression) []CodeDiagnostic {
+			r, err := newReview(filepath.Join("testdata", "test_config"), newModuleIndex())
+			require.NoError(t, err)
+			r.baseline = dir
+			r.config.Suppressions = append(r.config.Suppressions, s...)
+			review, err := r.Review()
+			require.NoError(t, err)
+			return review.Diagnostics
+		}
+		breaking := CodeDiagnostic{
+			DiagnosticID: breakingChangeID,
+			Level:        CodeDiagnosticLevelFatal,
+			TargetID:     "test_config",
+			Text:         breakingWithoutMajorBump + "test_config-Removed (removed)",
+		}
+		require.Contains(t, review(), breaking)
+
+		diagnostics := review(suppression{Rule: breakingChangeID, Target: "test_config", Justification: "Removed was never used."})
+		require.NotContains(t, diagnostics, breaking)
+		// the suppression is used
+		require.Len(t, diagnostics, 3)
+	})
+}
+
+func TestLoadConfig(t *testing.T) {
+	for _, test := range []struct {
+		name, file, content, err string
+		expected                 *config
+	}{
+		{
+			name:     "JSON",
+			file:     "apiviewgo.json",
+			content:  `{"rules": {"options-name": {"level": "info"}}, "suppressions": [{"target": "pkg-F", "justification": "why"}]}`,
+			expected: &config{Rules: map[string]ruleSettings{"options-name": {Level: "info"}}, Suppressions: []suppression{{Target: "pkg-F", Justification: "why"}}},
+		},
+		{
+			name:     "empty",
+			file:     "apiviewgo.yml",
+			expected: &config{},
+		},
+		{
+			name:     "built-in diagnostic",
+			file:     "apiviewgo.yaml",
+			content:  "rules:\n  exposes-internal-type:\n    level: warning\nsuppressions:\n  - rule: breaking-change\n    target: pkg\n    justification: why\n",
+			expected: &config{Rules: map[string]ruleSettings{exposesInternalTypeID: {Level: "warning"}}, Suppressions: []suppression{{Rule: breakingChangeID, Target: "pkg", Justification: "why"}}},
+		},
+		{
+			name:    "unknown field",
+			file:    "apiviewgo.json",
+			content: `{"rule": {}}`,
+			err:     "unknown field",
+		},
+		{
+			name:    "unknown rule",
+			file:    "apiviewgo.yaml",
+			content: "rules:\n  no-such-rule:\n    disabled: true\n",
+			err:     `unknown rule "no-such-rule"`,
+		},
+		{
+			name:    "unknown level",
+			file:    "apiviewgo.yaml",
+			content: "rules:\n  options-name:\n    level: severe\n",
+			err:     `unknown diagnostic level "severe"`,
+		},
+		{
+			name:    "no justification",
+			file:    "apiviewgo.yaml",
+			content: "suppressions:\n  - target: pkg-F\n",
+			err:     "suppression 1: justification is required",
+		},
+		{
+			name:    "no target",
+			file:    "apiviewgo.yaml",
+			content: "suppressions:\n  - justification: why\n",
+			err:     "suppression 1: target is required",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			p := filepath.Join(dir, test.file)
+			require.NoError(t, os.WriteFile(p, []byte(test.content), 0644))
+			cfg, err := loadConfig(dir)
+			if test.err != "" {
+				require.ErrorContains(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			test.expected.path = p
+			require.Equal(t, test.expected, cfg)
+		})
+	}
+
+	t.Run("none", func(t *testing.T) {
+		cfg, err := loadConfig(t.TempDir())
+		require.NoError(t, err)
+		require.Nil(t, cfg)
+	})
+
+	t.Run("multiple", func(t *testing.T) {
+		dir := t.TempDir()
+		for _, name := range []string{"apiviewgo.json", "apiviewgo.yaml"} {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0644))
+		}
+		_, err := loadConfig(dir)
+		require.ErrorContains(t, err, "found multiple config files")
+	})
+}
@@ -6,7 +6,10 @@ package cmd
 import (
 	"fmt"
 	"go/ast"
+	"go/constant"
 	"go/token"
+	"go/types"
+	"maps"
 	"regexp"
 	"slices"
 	"sort"
@@ -52,31 +55,173 @@ func newContent() content {
 	}
 }
 
+// clone returns a copy of c. Parsing content removes declarations from it, so that each appears once
+// in a review, and clones allow reviewing content more than once.
+func (c content) clone() content {
+	return content{
+		Consts:      maps.Clone(c.Consts),
+		Funcs:       maps.Clone(c.Funcs),
+		Interfaces:  maps.Clone(c.Interfaces),
+		SimpleTypes: maps.Clone(c.SimpleTypes),
+		Structs:     maps.Clone(c.Structs),
+		Vars:        maps.Clone(c.Vars),
+	}
+}
+
 // isEmpty returns true if there is no content in any of the fields.
 func (c content) isEmpty() bool {
 	return len(c.Consts)+len(c.Funcs)+len(c.Interfaces)+len(c.SimpleTypes)+len(c.Structs)+len(c.Vars) == 0
 }
 
-// addGenDecl adds const and var declaration to the exports list
+// tokenMakers returns the content's declarations
+func (c content) tokenMakers() []TokenMaker {
+	tms := []TokenMaker{}
+	for _, d := range c.Consts {
+		tms = append(tms, d)
+	}
+	for _, f := range c.Funcs {
+		tms = append(tms, f)
+	}
+	for _, i := range c.Interfaces {
+		tms = append(tms, i)
+	}
+	for _, s := range c.SimpleTypes {
+		tms = append(tms, s)
+	}
+	for _, s := range c.Structs {
+		tms = append(tms, s)
+	}
+	for _, v := range c.Vars {
+		tms = append(tms, v)
+	}
+	return tms
+}
+
+// addGenDecl adds a declaration of each name in a const or var spec to the exports list, returning those
+// declarations. iota is the spec's index in its const block. For a const spec that implicitly repeats the
+// previous spec's type and values, vs should have those.
 // The imports map stores the key value pair for package imports which will be used to identify types.
-func (c *content) addGenDecl(pkg Pkg, tok token.Token, vs *ast.ValueSpec, imports map[string]string) Declaration {
-	if len(vs.Values) > 0 {
-		v := getExprValue(pkg, vs.Values[0])
-		if v == "" {
-			fmt.Println("failed to determine value for " + pkg.getText(vs.Pos(), vs.End()))
-		}
-	}
-	decl := NewDeclaration(pkg, vs, imports)
-	// TODO handle multiple names like "var a, b = 42"
-	switch tok {
-	case token.CONST:
-		c.Consts[vs.Names[0].Name] = decl
-	case token.VAR:
-		c.Vars[vs.Names[0].Name] = decl
-	default:
-		fmt.Printf("unexpected declaration kind %v\n", vs.Names[0].Obj.Kind)
+func (c *content) addGenDecl(pkg Pkg, tok token.Token, vs *ast.ValueSpec, iota int, imports map[string]string) []Declaration {
+	decls := make([]Declaration, 0, len(vs.Names))
+	for i, name := range vs.Names {
+		if x := declValue(vs, i); x != nil && getExprValue(pkg, x) == "" {
+			pkg.warn(pkg.Name()+"."+name.Name, vs, "failed to determine value")
+		}
+		decl := NewDeclaration(pkg, vs, i, imports)
+		switch tok {
+		case token.CONST:
+			decl.cv = pkg.constValue(name, declValue(vs, i), iota)
+			if _, literal := declValue(vs, i).(*ast.BasicLit); decl.cv != nil && !literal {
+				// show the value of expressions such as "1 << iota", leaving literals as written
+				decl.value = formatConst(decl.cv)
+			}
+			c.Consts[name.Name] = decl
+		case token.VAR:
+			c.Vars[name.Name] = decl
+		default:
+			pkg.warn(pkg.Name(), vs, "unexpected declaration kind "+tok.String())
+		}
+		decls = append(decls, decl)
 	}
-	return decl
+	return decls
+}
+
+// constValue returns the value of the const declared by name, whose value is given by expr, or nil
+// when that isn't known. iota is the value of iota in expr.
+func (pkg Pkg) constValue(name *ast.Ident, expr ast.Expr, iota int) constant.Value {
+	if pkg.info != nil {
+		if c, ok := pkg.info.Defs[name].(*types.Const); ok && c.Val().Kind() != constant.Unknown {
+			return c.Val()
+		}
+	}
+	if expr == nil {
+		return nil
+	}
+	return pkg.evalConst(expr, iota)
+}
+
+// evalConst evaluates the constant expression expr, returning nil when it can't. It can't evaluate
+// expressions referring to constants it hasn't indexed, such as those declared in other packages.
+func (pkg Pkg) evalConst(expr ast.Expr, iota int) constant.Value {
+	switch x := expr.(type) {
+	case *ast.BasicLit:
+		if v := constant.MakeFromLiteral(x.Value, x.Kind, 0); v.Kind() != constant.Unknown {
+			return v
+		}
+	case *ast.BinaryExpr:
+		l, r := pkg.evalConst(x.X, iota), pkg.evalConst(x.Y, iota)
+		if l == nil || r == nil {
+			return nil
+		}
+		switch x.Op {
+		case token.SHL, token.SHR:
+			l = constant.ToInt(l)
+			if s, ok := constant.Uint64Val(constant.ToInt(r)); ok && l.Kind() == constant.Int {
+				return constant.Shift(l, x.Op, uint(s))
+			}
+			return nil
+		}
+		if !numeric(l) || !numeric(r) {
+			// constant operations panic when given a string or bool and a value of another kind
+			if l.Kind() != r.Kind() {
+				return nil
+			}
+		}
+		switch x.Op {
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+			return constant.MakeBool(constant.Compare(l, x.Op, r))
+		case token.QUO, token.REM:
+			if constant.Sign(r) == 0 {
+				return nil
+			}
+		}
+		op := x.Op
+		if op == token.QUO && l.Kind() == constant.Int && r.Kind() == constant.Int {
+			// integer division
+			op = token.QUO_ASSIGN
+		}
+		if v := constant.BinaryOp(l, op, r); v.Kind() != constant.Unknown {
+			return v
+		}
+	case *ast.CallExpr:
+		// a conversion such as "Color(1)". Converting an integer to a string changes its value.
+		if fn, ok := x.Fun.(*ast.Ident); len(x.Args) == 1 && !(ok && fn.Name == "string") {
+			return pkg.evalConst(x.Args[0], iota)
+		}
+	case *ast.Ident:
+		switch x.Name {
+		case "iota":
+			return constant.MakeInt64(int64(iota))
+		case "true", "false":
+			return constant.MakeBool(x.Name == "true")
+		}
+		if d, ok := pkg.c.Consts[x.Name]; ok {
+			return d.cv
+		}
+	case *ast.ParenExpr:
+		return pkg.evalConst(x.X, iota)
+	case *ast.UnaryExpr:
+		if v := pkg.evalConst(x.X, iota); v != nil && x.Op != token.XOR {
+			// ^x depends on the type of x, which we don't know
+			return constant.UnaryOp(x.Op, v, 0)
+		}
+	}
+	return nil
+}
+
+// numeric returns true when v is a number
+func numeric(v constant.Value) bool {
+	k := v.Kind()
+	return k == constant.Int || k == constant.Float || k == constant.Complex
+}
+
+// formatConst returns the text of a constant value as it would appear in Go source
+func formatConst(v constant.Value) string {
+	if v.Kind() == constant.Float {
+		// ExactString represents some floats as fractions
+		return v.String()
+	}
+	return v.ExactString()
 }
 
 // getExprValue returns a string representation of an expression's value. This is used to display
@@ -111,7 +256,7 @@ func getExprValue(pkg Pkg, expr ast.Expr) string {
 		// const FooConst = -1
 		return pkg.getText(x.Pos(), x.End())
 	default:
-		fmt.Printf("unhandled expression value type %T\n", expr)
+		pkg.warn(pkg.Name(), expr, fmt.Sprintf("unhandled expression value type %T", expr))
 		txt := pkg.getText(expr.Pos(), expr.End())
 		return txt
 	}
@@ -144,6 +289,7 @@ func (c *content) parseSimpleType() []ReviewLine {
 		if vars := c.filterDeclarations(t.Name(), c.Vars); len(vars) > 0 {
 			ln.Children = append(ln.Children, c.parseDeclarations(vars, "var")...)
 		}
+		lns = append(lns, makeDocLines(t.doc, t.ID())...)
 		lns = append(lns, ln)
 		lns = append(lns, ReviewLine{IsContextEndLine: true})
 	}
@@ -211,6 +357,7 @@ func (c *content) parseDeclarations(decls map[string]Declaration, kind string) [
 		}
 		for _, v := range finalKeys {
 			if d := decls[v]; d.Type == t {
+				ln.Children = append(ln.Children, makeDocLines(d.doc, d.ID())...)
 				ln.Children = append(ln.Children, ReviewLine{
 					LineID: d.ID(),
 					Tokens: d.MakeTokens(),
@@ -219,23 +366,23 @@ func (c *content) parseDeclarations(decls map[string]Declaration, kind string) [
 		}
 		if pvm := c.searchForPossibleValuesMethod(t); pvm != nil {
 			ln.Children = append(ln.Children, ReviewLine{})
-			ln.Children = append(ln.Children, *pvm)
+			ln.Children = append(ln.Children, pvm...)
 		}
 		ls = append(ls, ln)
 	}
 	return ls
 }
 
-// searchForPossibleValuesMethod returns a [ReviewLine] for the specified type's PossibleValues function,
-// if it exists, and deletes that function from the content so it isn't presented as an independent package-
-// level function by parseFunc. Note this means searchForPossibleValuesMethod must be called before parseFunc.
-// If the type doesn't have a corresponding PossibleValues function, searchForPossibleValuesMethod returns nil.
-func (c *content) searchForPossibleValuesMethod(t string) *ReviewLine {
+// searchForPossibleValuesMethod returns [ReviewLine]s for the specified type's PossibleValues function and
+// its documentation, if it exists, and deletes that function from the content so it isn't presented as an
+// independent package-level function by parseFunc. Note this means searchForPossibleValuesMethod must be
+// called before parseFunc. If the type doesn't have a corresponding PossibleValues function,
+// searchForPossibleValuesMethod returns nil.
+func (c *content) searchForPossibleValuesMethod(t string) []ReviewLine {
 	for i, f := range c.Funcs {
 		if f.Name() == fmt.Sprintf("Possible%sValues", removeNavigatorString(t)) {
-			fl := f.MakeReviewLine()
 			delete(c.Funcs, i)
-			return &fl
+			return append(makeDocLines(f.doc, f.ID()), f.MakeReviewLine())
 		}
 	}
 	return nil
@@ -259,20 +406,48 @@ func (c *content) addFunc(pkg Pkg, f *ast.FuncDecl, imports map[string]string) F
 
 // addSimpleType adds the specified simple type declaration to the exports list
 // The imports map stores the key value pair for package imports which will be used to identify types.
-func (c *content) addSimpleType(pkg Pkg, name, packageName string, underlyingType string, imports map[string]string) SimpleType {
-	t := NewSimpleType(name, packageName, pkg.translateType(underlyingType, imports))
+// underlying is the underlying type's expression in pkg, or nil when the type has no such expression.
+func (c *content) addSimpleType(pkg Pkg, name, packageName string, underlyingType string, underlying ast.Expr, tparams *ast.FieldList, doc *ast.CommentGroup, imports map[string]string) SimpleType {
+	t := NewSimpleType(name, packageName, pkg.translateExpr(underlying, underlyingType, imports))
+	t.typeTokens = pkg.typeTokens
+	t.typeParams = newTypeParams(pkg, tparams, imports)
+	t.doc = doc
 	c.SimpleTypes[name] = t
 	return t
 }
 
 // addInterface adds the specified interface type to the exports list.
 // The imports map stores the key value pair for package imports which will be used to identify types.
-func (c *content) addInterface(source Pkg, name, packageName string, i *ast.InterfaceType, imports map[string]string) Interface {
-	in := NewInterface(source, name, packageName, i, imports)
+func (c *content) addInterface(source Pkg, name, packageName string, ts *ast.TypeSpec, imports map[string]string) Interface {
+	in := NewInterface(source, name, packageName, ts, imports)
 	c.Interfaces[name] = in
 	return in
 }
 
+// isConstraint returns true when the named interface is a constraint i.e. it has type terms or embeds
+// an interface of this package that does
+func (c *content) isConstraint(name string) bool {
+	visited := map[string]bool{}
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		in, ok := c.Interfaces[name]
+		if !ok || visited[name] {
+			return false
+		}
+		visited[name] = true
+		if len(in.typeTerms) > 0 {
+			return true
+		}
+		for _, e := range in.embeddedInterfaces {
+			if e = removeNavigatorString(e); !strings.Contains(e, ".") && visit(e) {
+				return true
+			}
+		}
+		return false
+	}
+	return visit(name)
+}
+
 // adds the specified struct type to the exports list.
 func (c *content) parseInterface() []ReviewLine {
 	ls := []ReviewLine{}
@@ -284,8 +459,9 @@ func (c *content) parseInterface() []ReviewLine {
 	}
 	sort.Strings(keys)
 	for _, k := range keys {
-		il := c.Interfaces[k].MakeReviewLine()
-		ls = append(ls, il)
+		in := c.Interfaces[k]
+		ls = append(ls, makeDocLines(in.doc, in.ID())...)
+		ls = append(ls, in.MakeReviewLine())
 	}
 	return ls
 }
@@ -312,7 +488,8 @@ func (c *content) parseStructs() []ReviewLine {
 	}
 	sort.Strings(keys)
 	for _, typeName := range keys {
-		sl := c.Structs[typeName].MakeReviewLine()
+		s := c.Structs[typeName]
+		sl := s.MakeReviewLine()
 		ctors := c.searchForCtors(typeName)
 		methods := c.findMethods(typeName)
 		if len(sl.Children) > 0 && (len(ctors) > 0 || len(methods) > 0) {
@@ -328,6 +505,7 @@ func (c *content) parseStructs() []ReviewLine {
 			for _, k := range keys {
 				cl := ctors[k].MakeReviewLine()
 				cl.RelatedToLine = sl.LineID
+				sl.Children = append(sl.Children, makeDocLines(ctors[k].doc, cl.LineID)...)
 				sl.Children = append(sl.Children, cl)
 				delete(c.Funcs, k)
 			}
@@ -341,6 +519,7 @@ func (c *content) parseStructs() []ReviewLine {
 			for _, name := range names {
 				ml := methods[name].MakeReviewLine()
 				ml.RelatedToLine = sl.LineID
+				sl.Children = append(sl.Children, makeDocLines(methods[name].doc, ml.LineID)...)
 				sl.Children = append(sl.Children, ml)
 				delete(c.Funcs, name)
 			}
@@ -351,6 +530,7 @@ func (c *content) parseStructs() []ReviewLine {
 		if vars := c.filterDeclarations(typeName, c.Vars); len(vars) > 0 {
 			sl.Children = append(sl.Children, c.parseDeclarations(vars, "var")...)
 		}
+		ls = append(ls, makeDocLines(s.doc, s.ID())...)
 		ls = append(ls, sl)
 		ls = append(ls, ReviewLine{IsContextEndLine: true})
 	}
@@ -405,13 +585,7 @@ func (c *content) findMethods(s string) map[string]Func {
 		if unicode.IsLower(rune(name[0])) {
 			continue
 		}
-		_, n := getReceiver(key)
-		if before, _, found := strings.Cut(n, "["); found {
-			// ignore type parameters when matching receivers to types
-			n = before
-		}
-		n = removeNavigatorString(n)
-		if s == n || "*"+s == n {
+		if fn.receiverBase == s {
 			methods[key] = fn
 		}
 	}
@@ -430,6 +604,7 @@ func (c *content) searchForMethods(s string) []ReviewLine {
 	sort.Strings(methodNames)
 	for _, name := range methodNames {
 		fn := methods[name]
+		lines = append(lines, makeDocLines(fn.doc, fn.ID())...)
 		lines = append(lines, fn.MakeReviewLine())
 		delete(c.Funcs, name)
 	}
@@ -480,7 +655,9 @@ func (c *content) parseFunc() []ReviewLine {
 	}
 	sort.Strings(keys)
 	for _, k := range keys {
-		lns = append(lns, c.Funcs[k].MakeReviewLine())
+		fn := c.Funcs[k]
+		lns = append(lns, makeDocLines(fn.doc, fn.ID())...)
+		lns = append(lns, fn.MakeReviewLine())
 	}
 	return lns
 }
@@ -519,12 +696,16 @@ func (c *content) generateNavChildItems() []NavigationItem {
 	}
 	for _, i := range c.Interfaces {
 		if i.Exported() {
+			kind := "interface"
+			if c.isConstraint(i.Name()) {
+				kind = "constraint"
+			}
 			items = append(items, NavigationItem{
 				Text:         i.Name(),
 				NavigationID: i.ID(),
 				ChildItems:   []NavigationItem{},
 				Tags: &map[string]string{
-					"TypeKind": "interface",
+					"TypeKind": kind,
 				},
 			})
 		}
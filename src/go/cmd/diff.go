@@ -0,0 +1,232 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff <oldModuleDir> <newModuleDir>",
+	Short: "Report API changes between two versions of a module",
+	Long: `diff compares the public APIs of two versions of an Azure SDK for Go module, reporting
+declarations added, removed and changed in the new version and whether each change breaks
+compatibility. It writes a human-readable report to stdout and, when --json is set, a
+machine-readable report to the given file. It exits with code 4 when the new version breaks
+compatibility without a new major version.`,
+	Args: usageArgs(cobra.ExactArgs(2)),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		for _, dir := range args {
+			if err := validateModuleDir(dir); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := diffModules(args[0], args[1])
+		if err != nil {
+			return parseError(err)
+		}
+		if err = d.WriteText(cmd.OutOrStdout()); err != nil {
+			return err
+		}
+		if diffJSON != "" {
+			b, err := json.MarshalIndent(d, "", " ")
+			if err != nil {
+				return err
+			}
+			if err = os.WriteFile(diffJSON, b, 0644); err != nil {
+				return err
+			}
+		}
+		if n := len(d.Breaking()); n > 0 && !d.MajorVersionBump {
+			return &codeError{code: exitFatal, err: fmt.Errorf("%d breaking change(s) require a new major version", n)}
+		}
+		return nil
+	},
+}
+
+// diffJSON is the path of the JSON report diffCmd writes, if any
+var diffJSON string
+
+func init() {
+	diffCmd.Flags().StringVar(&diffJSON, "json", "", "also write a JSON report to this file")
+	rootCmd.AddCommand(diffCmd)
+}
+
+// APIDiff describes the differences between two versions of a module's API. Declarations are
+// identified by the LineID of the ReviewLine presenting them.
+type APIDiff struct {
+	Added   []LineDiff `json:"Added"`
+	Changed []LineDiff `json:"Changed"`
+	// MajorVersionBump indicates whether the new version may break compatibility because it has a greater
+	// major version than the old or is an unstable v0 version
+	MajorVersionBump bool       `json:"MajorVersionBump"`
+	Removed          []LineDiff `json:"Removed"`
+}
+
+// LineDiff describes a declaration's change between two versions of a module's API
+type LineDiff struct {
+	// Breaking indicates whether the change breaks compatibility according to Go's compatibility rules
+	Breaking bool   `json:"Breaking"`
+	LineID   string `json:"LineId"`
+	// New is the declaration's text in the new version. It's empty when the declaration was removed.
+	New string `json:"New,omitempty"`
+	// Old is the declaration's text in the old version. It's empty when the declaration was added.
+	Old string `json:"Old,omitempty"`
+	// Reason describes the change e.g. "removed" or "signature changed"
+	Reason string `json:"Reason"`
+}
+
+// diffModules returns the API differences between the modules at the given paths
+func diffModules(oldDir, newDir string) (APIDiff, error) {
+	oldFile, oldPath, err := reviewModule(oldDir, "")
+	if err != nil {
+		return APIDiff{}, err
+	}
+	newFile, newPath, err := reviewModule(newDir, "")
+	if err != nil {
+		return APIDiff{}, err
+	}
+	d := diffCodeFiles(oldFile, newFile)
+	d.MajorVersionBump = majorVersionBump(oldPath, oldFile.PackageVersion, newPath, newFile.PackageVersion)
+	return d, nil
+}
+
+// diffCodeFiles returns the API differences between two CodeFiles
+func diffCodeFiles(oldFile, newFile CodeFile) APIDiff {
+	d := APIDiff{Added: []LineDiff{}, Changed: []LineDiff{}, Removed: []LineDiff{}}
+	before, after := byDiffKey(declarations(oldFile.ReviewLines)), byDiffKey(declarations(newFile.ReviewLines))
+	sealed := sealedInterfaces(oldFile)
+	for key, o := range before {
+		if n, ok := after[key]; !ok {
+			d.Removed = append(d.Removed, LineDiff{Breaking: true, LineID: o.lineID, Old: o.text, Reason: reasonRemoved})
+		} else if n.text != o.text {
+			breaking, reason := classifyChanged(o, n)
+			d.Changed = append(d.Changed, LineDiff{Breaking: breaking, LineID: n.lineID, New: n.text, Old: o.text, Reason: reason})
+		}
+	}
+	for key, n := range after {
+		if _, ok := before[key]; !ok {
+			breaking, reason := classifyAdded(n, before, sealed)
+			d.Added = append(d.Added, LineDiff{Breaking: breaking, LineID: n.lineID, New: n.text, Reason: reason})
+		}
+	}
+	for _, lds := range [][]LineDiff{d.Added, d.Changed, d.Removed} {
+		sort.Slice(lds, func(i, j int) bool { return lds[i].LineID < lds[j].LineID })
+	}
+	return d
+}
+
+// byDiffKey returns decls, a map of LineIDs to declarations, keyed by diffKey instead
+func byDiffKey(decls map[string]declaration) map[string]declaration {
+	keyed := make(map[string]declaration, len(decls))
+	for id, d := range decls {
+		keyed[diffKey(id)] = d
+	}
+	return keyed
+}
+
+// Breaking returns the changes that break compatibility
+func (d APIDiff) Breaking() []LineDiff {
+	breaking := []LineDiff{}
+	for _, lds := range [][]LineDiff{d.Removed, d.Changed, d.Added} {
+		for _, ld := range lds {
+			if ld.Breaking {
+				breaking = append(breaking, ld)
+			}
+		}
+	}
+	return breaking
+}
+
+// Empty returns true when the API didn't change
+func (d APIDiff) Empty() bool {
+	return len(d.Added)+len(d.Changed)+len(d.Removed) == 0
+}
+
+// WriteText writes a human-readable report of the differences to w
+func (d APIDiff) WriteText(w io.Writer) error {
+	if d.Empty() {
+		_, err := fmt.Fprintln(w, "No API changes")
+		return err
+	}
+	sb := strings.Builder{}
+	for _, section := range []struct {
+		title string
+		lds   []LineDiff
+	}{
+		{"Removed", d.Removed},
+		{"Changed", d.Changed},
+		{"Added", d.Added},
+	} {
+		if len(section.lds) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "%s (%d):\n", section.title, len(section.lds))
+		for _, ld := range section.lds {
+			if ld.Breaking {
+				fmt.Fprintf(&sb, "  %s (breaking: %s)\n", ld.LineID, ld.Reason)
+			} else {
+				fmt.Fprintf(&sb, "  %s\n", ld.LineID)
+			}
+			if ld.Old != "" {
+				fmt.Fprintf(&sb, "    - %s\n", ld.Old)
+			}
+			if ld.New != "" {
+				fmt.Fprintf(&sb, "    + %s\n", ld.New)
+			}
+		}
+	}
+	if n := len(d.Breaking()); n > 0 {
+		if d.MajorVersionBump {
+			fmt.Fprintf(&sb, "%d breaking change(s) in a new major version\n", n)
+		} else {
+			fmt.Fprintf(&sb, "%d breaking change(s) require a new major version\n", n)
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// lineText returns the text of a line's tokens, spaced according to the tokens' HasPrefixSpace
+// and HasSuffixSpace fields. Whitespace tokens collapse to a single space. When include isn't nil,
+// lineText omits tokens for which it returns false.
+func lineText(ln ReviewLine, include func(ReviewToken) bool) string {
+	sb := strings.Builder{}
+	lineTokens(ln, include, func(tk ReviewToken, space bool) {
+		if space {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(tk.Value)
+	})
+	return sb.String()
+}
+
+// lineTokens calls fn with each token of ln that isn't whitespace, in order, and whether a space
+// should precede the token as described by lineText. When include isn't nil, lineTokens omits
+// tokens for which it returns false.
+func lineTokens(ln ReviewLine, include func(ReviewToken) bool, fn func(tk ReviewToken, space bool)) {
+	space, first := false, true
+	for _, tk := range ln.Tokens {
+		if include != nil && !include(tk) {
+			continue
+		}
+		if strings.TrimSpace(tk.Value) == "" {
+			space = true
+			continue
+		}
+		fn(tk, (space || tk.HasPrefixSpace) && !first)
+		space, first = tk.HasSuffixSpace, false
+	}
+}
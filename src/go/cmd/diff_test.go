@@ -0,0 +1,160 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	d, err := diffModules(filepath.Clean("testdata/test_diff/old"), filepath.Clean("testdata/test_diff/new"))
+	require.NoError(t, err)
+	require.False(t, d.MajorVersionBump)
+	require.Equal(t, []LineDiff{
+		{LineID: "test_diff-Added", New: "func Added()", Reason: reasonAdded},
+		{Breaking: true, LineID: "test_diff.Doer-Undo", New: "Undo() error", Reason: reasonMethodAdded},
+	}, d.Added)
+	require.Equal(t, []LineDiff{
+		{
+			Breaking: true,
+			LineID:   "test_diff-(c *Client) Do",
+			New:      "func (*Client) Do(name string, count int) error",
+			Old:      "func (*Client) Do(name string) error",
+			Reason:   reasonSignatureChanged,
+		},
+		{
+			// renaming a receiver or parameter doesn't break compatibility
+			LineID: "test_diff-(client *Client) Get",
+			New:    "func (*Client) Get(widgetName string) (string, error)",
+			Old:    "func (*Client) Get(name string) (string, error)",
+			Reason: reasonNamesChanged,
+		},
+		{LineID: "test_diff.ColorRed", Old: `ColorRed Color = "red"`, New: `ColorRed Color = "crimson"`, Reason: reasonValueChanged},
+	}, d.Changed)
+	require.Equal(t, []LineDiff{
+		{Breaking: true, LineID: "test_diff-Removed", Old: "func Removed()", Reason: reasonRemoved},
+		{Breaking: true, LineID: "test_diff.Client-Retries", Old: "Retries int", Reason: reasonRemoved},
+	}, d.Removed)
+	require.Len(t, d.Breaking(), 4)
+
+	b, err := json.Marshal(d)
+	require.NoError(t, err)
+	var unmarshaled APIDiff
+	require.NoError(t, json.Unmarshal(b, &unmarshaled))
+	require.Equal(t, d, unmarshaled)
+
+	buf := bytes.Buffer{}
+	require.NoError(t, d.WriteText(&buf))
+	require.Equal(t, `Removed (2):
+  test_diff-Removed (breaking: removed)
+    - func Removed()
+  test_diff.Client-Retries (breaking: removed)
+    - Retries int
+Changed (3):
+  test_diff-(c *Client) Do (breaking: signature changed)
+    - func (*Client) Do(name string) error
+    + func (*Client) Do(name string, count int) error
+  test_diff-(client *Client) Get
+    - func (*Client) Get(name string) (string, error)
+    + func (*Client) Get(widgetName string) (string, error)
+  test_diff.ColorRed
+    - ColorRed Color = "red"
+    + ColorRed Color = "crimson"
+Added (2):
+  test_diff-Added
+    + func Added()
+  test_diff.Doer-Undo (breaking: method added to an interface applications can implement)
+    + Undo() error
+4 breaking change(s) require a new major version
+`, buf.String())
+}
+
+func TestBreakingChangeDiagnostics(t *testing.T) {
+	review := reviewWithBaseline(t, filepath.Clean("testdata/test_diff/new"), filepath.Clean("testdata/test_diff/old"))
+	lineIDs := map[string]bool{}
+	forAll(review.ReviewLines, func(rl ReviewLine) {
+		lineIDs[rl.LineID] = true
+	})
+	actual := map[string]string{}
+	for _, d := range review.Diagnostics {
+		if d.Level != CodeDiagnosticLevelFatal {
+			continue
+		}
+		require.True(t, lineIDs[d.TargetID], "no LineID corresponds to TargetID %q", d.TargetID)
+		actual[d.Text] = d.TargetID
+	}
+	require.Equal(t, map[string]string{
+		breakingWithoutMajorBump + "test_diff-(c *Client) Do (signature changed)":                                  "test_diff-(c *Client) Do",
+		breakingWithoutMajorBump + "test_diff-Removed (removed)":                                                   "test_diff",
+		breakingWithoutMajorBump + "test_diff.Client-Retries (removed)":                                            "test_diff.Client",
+		breakingWithoutMajorBump + "test_diff.Doer-Undo (method added to an interface applications can implement)": "test_diff.Doer-Undo",
+	}, actual)
+
+	t.Run("major version bump", func(t *testing.T) {
+		d, err := diffModules(filepath.Clean("testdata/test_diff/old"), filepath.Clean("testdata/test_diff/v2"))
+		require.NoError(t, err)
+		require.True(t, d.MajorVersionBump)
+		require.Len(t, d.Breaking(), 4)
+
+		review := reviewWithBaseline(t, filepath.Clean("testdata/test_diff/v2"), filepath.Clean("testdata/test_diff/old"))
+		for _, d := range review.Diagnostics {
+			require.NotEqual(t, CodeDiagnosticLevelFatal, d.Level)
+		}
+	})
+}
+
+// reviewWithBaseline returns a review of the module in dir having diagnostics for breaking changes
+// from the module in baselineDir
+func reviewWithBaseline(t *testing.T, dir, baselineDir string) CodeFile {
+	r, err := newReview(dir, newModuleIndex())
+	require.NoError(t, err)
+	r.baseline = baselineDir
+	review, err := r.Review()
+	require.NoError(t, err)
+	return review
+}
+
+func TestMajorVersionSuffix(t *testing.T) {
+	for modPath, expected := range map[string]int{
+		"foo":        1,
+		"foo/v2":     2,
+		"foo/v2/bar": 1,
+		"github.com/Azure/azure-sdk-for-go/sdk/foo/v12": 12,
+	} {
+		require.Equal(t, expected, majorVersion(modPath), modPath)
+	}
+}
+
+func TestDiffNoChanges(t *testing.T) {
+	d, err := diffModules(filepath.Clean("testdata/test_diff/new"), filepath.Clean("testdata/test_diff/new"))
+	require.NoError(t, err)
+	require.True(t, d.Empty())
+	buf := bytes.Buffer{}
+	require.NoError(t, d.WriteText(&buf))
+	require.Equal(t, "No API changes\n", buf.String())
+}
+
+func TestMajorVersionBump(t *testing.T) {
+	for _, test := range []struct {
+		oldPath, oldVersion, newPath, newVersion string
+		expected                                 bool
+	}{
+		{oldPath: "foo", newPath: "foo"},
+		{oldPath: "foo", newPath: "foo/v2", expected: true},
+		{oldPath: "foo/v2", newPath: "foo/v3", expected: true},
+		{oldPath: "foo", oldVersion: "v1.0.0", newPath: "foo", newVersion: "v1.1.0"},
+		{oldPath: "foo", oldVersion: "v0.1.0", newPath: "foo", newVersion: "v0.2.0", expected: true},
+		{oldPath: "foo", oldVersion: "v0.9.0", newPath: "foo", newVersion: "v1.0.0", expected: true},
+		{oldPath: "foo", newPath: "foo", newVersion: "v1.0.0"},
+		{oldPath: "foo/v2", oldVersion: "v2.0.0", newPath: "foo/v2", newVersion: "v2.1.0"},
+	} {
+		actual := majorVersionBump(test.oldPath, test.oldVersion, test.newPath, test.newVersion)
+		require.Equal(t, test.expected, actual, "%+v", test)
+	}
+}
@@ -5,14 +5,19 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"net/url"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"time"
 
 	"golang.org/x/mod/module"
@@ -22,9 +27,11 @@ import (
 var errCachedModuleNotFound = errors.New("cached module not found")
 
 // GetExternalModule returns a Module representing mod. When GOMODCACHE is set,
-// it looks for mod's source in the mod cache. Otherwise, it downloads mod from
-// the module proxy.
-func GetExternalModule(mod module.Version) (*Module, error) {
+// it looks for mod's source in the mod cache. Otherwise, it downloads mod as
+// described by downloadModule, verifying the download against sums, the
+// reviewed module's go.sum. When --cache is set, it keeps downloaded modules
+// in the apiviewgo cache for later runs.
+func GetExternalModule(mod module.Version, sums goSum) (*Module, error) {
 	m, err := cachedModule(mod)
 	if err != nil && !errors.Is(err, errCachedModuleNotFound) {
 		return nil, fmt.Errorf("failed to parse cached module %s: %w", mod.Path, err)
@@ -32,91 +39,245 @@ func GetExternalModule(mod module.Version) (*Module, error) {
 	if m == nil {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
 		defer cancel()
-		m, err = downloadModule(ctx, mod)
+		if useCache {
+			var c moduleCache
+			if c, err = openModuleCache(); err == nil {
+				m, err = c.get(ctx, mod, sums)
+			}
+		} else {
+			m, err = downloadTemporarily(ctx, mod, sums)
+		}
 	}
 	return m, err
 }
 
-// downloadModule downloads mod from the Go module proxy, storing it in a temporary directory.
-// That directory looks like:
+// downloadTemporarily downloads mod to a temporary directory, returning a Module representing it. It
+// removes the directory before returning.
+func downloadTemporarily(ctx context.Context, mod module.Version, sums goSum) (*Module, error) {
+	d, err := downloadDir()
+	if err != nil {
+		return nil, err
+	}
+	// Without --cache, we don't keep downloaded content because an apiviewgo instance doesn't need
+	// to download any mod twice (Review caches the Module this function returns).
+	defer removeDownloadDir(d)
+	modver := path.Base(mod.Path) + "@" + mod.Version
+	dir, _, err := downloadModule(ctx, mod, sums, filepath.Join(d, modver, mustEscape(mod.Path))+"@"+mod.Version)
+	if err != nil {
+		return nil, err
+	}
+	return NewModule(dir)
+}
+
+// proxyClient is the HTTP client for requests to module proxies
+var proxyClient = &http.Client{}
+
+// downloadModule downloads mod as the go command would, from the proxies listed in GOPROXY or, for
+// modules matching GONOPROXY or GOPRIVATE, directly from version control. As with the go command,
+// downloadModule tries the next proxy when one doesn't have the module or, when the proxies are
+// separated by "|", after any error. It verifies the download as described by verifyModuleZip and
+// never tries another proxy after a checksum mismatch. It returns the verified h1: hash of mod's zip
+// and the directory containing mod's source: dest, which must not exist, for modules downloaded from
+// a proxy, or a directory in the module cache for modules downloaded directly. Because a failed download may leave part of the
+// module in dest, downloadModule removes dest before trying each proxy.
+func downloadModule(ctx context.Context, mod module.Version, sums goSum, dest string) (string, string, error) {
+	env := goEnv("GOFLAGS", "GONOPROXY", "GONOSUMDB", "GOPRIVATE", "GOPROXY", "GOSUMDB")
+	errs := []error{}
+	for _, p := range moduleProxies(mod.Path, env) {
+		dir, h := dest, ""
+		var err error
+		switch p.url {
+		case "direct":
+			dir, h, err = downloadDirect(ctx, mod, env, sums)
+		case "off":
+			err = fmt.Errorf("can't download %s because GOPROXY=off", mod)
+		default:
+			if err = os.RemoveAll(dest); err != nil {
+				return "", "", fmt.Errorf("failed to remove %s: %w", dest, err)
+			}
+			h, err = downloadFromProxy(ctx, p.url, mod, sums, env, dest)
+		}
+		if err == nil {
+			return dir, h, nil
+		}
+		if errors.Is(err, errChecksumMismatch) {
+			return "", "", err
+		}
+		errs = append(errs, err)
+		if !p.fallBackOnError && !errors.Is(err, errNotFound) {
+			break
+		}
+	}
+	if len(errs) == 0 {
+		return "", "", fmt.Errorf("can't download %s because GOPROXY lists no proxies", mod)
+	}
+	return "", "", errors.Join(errs...)
+}
+
+// downloadFromProxy downloads mod from the module proxy at proxyURL and unzips it to dest. It stores
+// the zip in a temporary directory, which looks like:
 //
 //	~/apiviewgo{random suffix}
-//	├── azcore@v1.0.0
-//	│   └── github.com/!azure/azure-sdk-for-go/sdk/azcore@v1.0.0
-//	│       └── go.mod
 //	└── zip
 //	    └── github.com/!azure/azure-sdk-for-go/sdk/azcore
 //	        └── v1.0.0.zip
 //
-// This is perhaps odd but zip.Unzip() requires the target directory be entirely empty, so
-// obvious tidier schemes are impossible. Although downloadModule could in principle unzip
-// modules to the local Go module cache, it doesn't do so to avoid affecting other Go programs
-// or reimplementing whatever `go mod download` behavior is necessary to ensure correctness.
-func downloadModule(ctx context.Context, mod module.Version) (*Module, error) {
+// zip.Unzip() requires dest be entirely empty, so the zip can't be stored there. Although
+// downloadFromProxy could in principle unzip modules to the local Go module cache, it doesn't do
+// so to avoid affecting other Go programs or reimplementing whatever `go mod download` behavior
+// is necessary to ensure correctness. downloadFromProxy verifies the zip with verifyModuleZip
+// before unzipping it and returns the zip's hash.
+func downloadFromProxy(ctx context.Context, proxyURL string, mod module.Version, sums goSum, env map[string]string, dest string) (string, error) {
 	d, err := downloadDir()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	// We don't keep downloaded content because an apiviewgo instance doesn't need to download
-	// any mod twice (Review caches the Module this function returns) and we don't want to
-	// maintain a cache given the low performance impact of downloading modules (very few SDK
-	// modules export types defined elsewhere).
-	defer func() {
-		if err := os.RemoveAll(d); err != nil {
-			fmt.Fprintf(os.Stderr, "failed to remove download directory %s: %v", d, err)
-		}
-	}()
+	defer removeDownloadDir(d)
 	escaped, err := module.EscapePath(mod.Path)
 	if err != nil {
-		return nil, fmt.Errorf("unescapeable module path %q: %w", mod.Path, err)
+		return "", fmt.Errorf("unescapeable module path %q: %w", mod.Path, err)
 	}
-	u, err := url.Parse("https://" + path.Join("proxy.golang.org", escaped, "@v", mod.Version+".zip"))
+	escapedVersion, err := module.EscapeVersion(mod.Version)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse module URL: %w", err)
+		return "", fmt.Errorf("unescapeable module version %q: %w", mod.Version, err)
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	u, err := url.Parse(strings.TrimSuffix(proxyURL, "/") + "/" + path.Join(escaped, "@v", escapedVersion+".zip"))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return "", fmt.Errorf("failed to parse module URL: %w", err)
 	}
-	resp, err := http.DefaultClient.Do(req)
+	body, err := openProxyFile(ctx, u)
 	if err != nil {
-		return nil, fmt.Errorf("failed to download module zip from %s: %w", u, err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		msg := "module proxy responded %d"
-		if resp.Body != nil {
-			b, _ := io.ReadAll(resp.Body)
-			msg += ": " + string(b)
-		}
-		return nil, fmt.Errorf(msg, resp.StatusCode)
+		return "", err
 	}
+	defer body.Close()
 	zp := filepath.Join(d, "zip", mustEscape(mod.Path), mod.Version+".zip")
 	err = os.MkdirAll(filepath.Dir(zp), 0700)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create directory for %s: %w", zp, err)
+		return "", fmt.Errorf("failed to create directory for %s: %w", zp, err)
 	}
 	f, err := os.Create(zp)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create %s: %w", zp, err)
+		return "", fmt.Errorf("failed to create %s: %w", zp, err)
 	}
 	defer f.Close()
-	_, err = io.Copy(f, resp.Body)
+	_, err = io.Copy(f, body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to write %s: %w", zp, err)
+		return "", fmt.Errorf("failed to write %s: %w", zp, err)
 	}
-	modver := path.Base(mod.Path) + "@" + mod.Version
-	p := filepath.Join(d, modver, mustEscape(mod.Path)) + "@" + mod.Version
-	err = zip.Unzip(p, mod, zp)
+	h, err := verifyModuleZip(ctx, mod, zp, sums, env)
+	if err != nil {
+		return "", err
+	}
+	err = zip.Unzip(dest, mod, zp)
+	if err != nil {
+		return "", fmt.Errorf("failed to unzip %s: %w", zp, err)
+	}
+	return h, nil
+}
+
+// fileURLPath returns the path of the file at u, a file URL, converting it as the go command does. When
+// windows is true, a host names a UNC server, as in file://server/share/proxy, and otherwise the path must
+// begin with a drive letter, as in file:///C:/proxy. On other systems, the host must be empty or "localhost".
+func fileURLPath(u *url.URL, windows bool) (string, error) {
+	p := u.Path
+	if p == "" || p[0] != '/' {
+		return "", fmt.Errorf("file URL %s has no absolute path", u)
+	}
+	host := u.Host
+	if host == "localhost" {
+		host = ""
+	}
+	if !windows {
+		if host != "" {
+			return "", fmt.Errorf("file URL %s specifies a non-local host", u)
+		}
+		return p, nil
+	}
+	p = strings.ReplaceAll(p, "/", `\`)
+	if host != "" {
+		if hasDriveLetter(host) {
+			return "", fmt.Errorf("file URL %s encodes a drive letter in its host; too few slashes?", u)
+		}
+		return `\\` + host + p, nil
+	}
+	if !hasDriveLetter(p[1:]) {
+		return "", fmt.Errorf("file URL %s has no drive letter", u)
+	}
+	return p[1:], nil
+}
+
+// hasDriveLetter returns whether the Windows path p begins with a drive letter such as "C:".
+func hasDriveLetter(p string) bool {
+	if len(p) < 2 || p[1] != ':' || (len(p) > 2 && p[2] != '\\') {
+		return false
+	}
+	c := p[0] | 0x20
+	return 'a' <= c && c <= 'z'
+}
+
+// openProxyFile opens the file at u, a URL of a file served by a module proxy. u may have the file scheme,
+// for proxies on the local file system. Errors wrap errNotFound when the proxy doesn't have the file.
+func openProxyFile(ctx context.Context, u *url.URL) (io.ReadCloser, error) {
+	if u.Scheme == "file" {
+		p, err := fileURLPath(u, runtime.GOOS == "windows")
+		if err != nil {
+			return nil, err
+		}
+		f, err := os.Open(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			err = fmt.Errorf("%w: %w", errNotFound, err)
+		}
+		return f, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unzip %s: %w", zp, err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	return NewModule(p)
+	resp, err := proxyClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download module zip from %s: %w", u, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		err = fmt.Errorf("module proxy %s responded %d: %s", u.Host, resp.StatusCode, b)
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+			err = fmt.Errorf("%w: %w", errNotFound, err)
+		}
+		return nil, err
+	}
+	return resp.Body, nil
 }
 
-// cachedModule returns a Module for mod if it's in either the local Go mod
-// cache or apiviewgo cache. It returns errCachedModuleNotFound when the
-// module isn't in either cache.
+// downloadDirect downloads mod from its version control repository with the go command, which adds it
+// to the module cache, and returns the directory containing mod's source and the h1: hash of its zip.
+// The go command verifies the download against the checksum database, so downloadDirect verifies only
+// that it matches sums. env has the values of GOFLAGS, GONOSUMDB and GOSUMDB for the go command.
+func downloadDirect(ctx context.Context, mod module.Version, env map[string]string, sums goSum) (string, string, error) {
+	cmd := exec.CommandContext(ctx, "go", "mod", "download", "-json", mod.String())
+	// run outside any module so the reviewed module's go.mod doesn't affect the download
+	cmd.Dir = os.TempDir()
+	cmd.Env = append(os.Environ(), "GOPROXY=direct", "GOFLAGS="+env["GOFLAGS"], "GONOSUMDB="+env["GONOSUMDB"], "GOSUMDB="+env["GOSUMDB"])
+	out, err := cmd.Output()
+	info := struct {
+		Dir   string
+		Error string
+		Sum   string
+	}{}
+	if jerr := json.Unmarshal(out, &info); jerr == nil && info.Error != "" {
+		return "", "", fmt.Errorf("failed to download %s: %s", mod, info.Error)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to download %s: %w", mod, err)
+	}
+	if want, ok := sums[mod]; ok && info.Sum != want {
+		return "", "", fmt.Errorf("%w for %s: downloaded %s but go.sum has %s", errChecksumMismatch, mod, info.Sum, want)
+	}
+	return info.Dir, info.Sum, nil
+}
+
+// cachedModule returns a Module for mod if it's in the local Go mod cache.
+// It returns errCachedModuleNotFound when the module isn't there.
 func cachedModule(mod module.Version) (*Module, error) {
 	if modCache := os.Getenv("GOMODCACHE"); modCache != "" {
 		d := filepath.Join(modCache, mustEscape(mod.Path)) + "@" + mod.Version
@@ -150,3 +311,10 @@ func downloadDir() (string, error) {
 	}
 	return d, err
 }
+
+// removeDownloadDir removes d, a directory created by downloadDir, logging any failure
+func removeDownloadDir(d string) {
+	if err := os.RemoveAll(d); err != nil {
+		logger.Warn("failed to remove download directory", "dir", d, "err", err)
+	}
+}
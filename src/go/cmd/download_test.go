@@ -0,0 +1,247 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	stdzip "archive/zip"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/dirhash"
+	"golang.org/x/mod/sumdb/note"
+	"golang.org/x/mod/zip"
+)
+
+func TestModuleProxies(t *testing.T) {
+	for _, test := range []struct {
+		env      map[string]string
+		expected []moduleProxy
+	}{
+		{
+			env:      map[string]string{},
+			expected: []moduleProxy{{url: "https://proxy.golang.org"}, {url: "direct"}},
+		},
+		{
+			env: map[string]string{"GOPROXY": "https://a.example.com|b.example.com,file:///srv/proxy"},
+			expected: []moduleProxy{
+				{url: "https://a.example.com", fallBackOnError: true},
+				{url: "https://b.example.com"},
+				{url: "file:///srv/proxy"},
+			},
+		},
+		{
+			// proxies after "off" and "direct" are never tried
+			env:      map[string]string{"GOPROXY": "https://a.example.com,off,https://b.example.com"},
+			expected: []moduleProxy{{url: "https://a.example.com"}, {url: "off"}},
+		},
+		{
+			env:      map[string]string{"GOPRIVATE": "*.corp.example.com,github.com/Azure/private"},
+			expected: []moduleProxy{{url: "direct"}},
+		},
+		{
+			// GONOPROXY takes precedence over GOPRIVATE
+			env:      map[string]string{"GONOPROXY": "none.example.com", "GOPRIVATE": "github.com/Azure/private", "GOPROXY": "https://a.example.com"},
+			expected: []moduleProxy{{url: "https://a.example.com"}},
+		},
+	} {
+		require.Equal(t, test.expected, moduleProxies("github.com/Azure/private/mod", test.env), test.env)
+	}
+}
+
+// fileProxy creates a file:// module proxy serving the module in dir as mod. It returns the
+// proxy's URL and the h1: hash of the module's zip.
+func fileProxy(t *testing.T, mod module.Version, dir string) (string, string) {
+	proxy := t.TempDir()
+	zp := filepath.Join(proxy, filepath.FromSlash(mustEscape(mod.Path)), "@v", mod.Version+".zip")
+	require.NoError(t, os.MkdirAll(filepath.Dir(zp), 0700))
+	f, err := os.Create(zp)
+	require.NoError(t, err)
+	require.NoError(t, zip.CreateFromDir(f, mod, dir))
+	require.NoError(t, f.Close())
+	h, err := dirhash.HashZip(zp, dirhash.Hash1)
+	require.NoError(t, err)
+	return "file://" + filepath.ToSlash(proxy), h
+}
+
+// download downloads mod with downloadModule to a temporary directory and indexes it
+func download(t *testing.T, mod module.Version, sums goSum) (*Module, error) {
+	dir, _, err := downloadModule(context.Background(), mod, sums, filepath.Join(t.TempDir(), "src"))
+	if err != nil {
+		return nil, err
+	}
+	return NewModule(dir)
+}
+
+func TestDownloadFromFileProxy(t *testing.T) {
+	mod := module.Version{Path: "example.com/vars", Version: "v1.0.0"}
+	proxy, _ := fileProxy(t, mod, filepath.Join("testdata", "test_vars"))
+	empty := "file://" + filepath.ToSlash(t.TempDir())
+
+	t.Setenv("GOFLAGS", "")
+	t.Setenv("GONOPROXY", "")
+	t.Setenv("GOPRIVATE", "")
+	t.Setenv("GOSUMDB", "off")
+	for _, goproxy := range []string{
+		proxy,
+		// the first proxy doesn't have the module, so downloadModule tries the second
+		empty + "," + proxy,
+	} {
+		t.Setenv("GOPROXY", goproxy)
+		m, err := download(t, mod, nil)
+		require.NoError(t, err)
+		require.Contains(t, m.Packages, "test_vars")
+	}
+
+	t.Setenv("GOPROXY", empty+",off")
+	_, err := download(t, mod, nil)
+	require.ErrorIs(t, err, errNotFound)
+	require.ErrorContains(t, err, "GOPROXY=off")
+}
+
+func TestDownloadAfterFailedUnzip(t *testing.T) {
+	mod := module.Version{Path: "example.com/vars", Version: "v1.0.0"}
+	proxy, _ := fileProxy(t, mod, filepath.Join("testdata", "test_vars"))
+
+	// the first proxy serves a zip whose second file's name is too long for the file system, so
+	// unzipping it fails after writing the first file to dest
+	corrupt := t.TempDir()
+	zp := filepath.Join(corrupt, filepath.FromSlash(mustEscape(mod.Path)), "@v", mod.Version+".zip")
+	require.NoError(t, os.MkdirAll(filepath.Dir(zp), 0700))
+	f, err := os.Create(zp)
+	require.NoError(t, err)
+	zw := stdzip.NewWriter(f)
+	for _, name := range []string{"go.mod", strings.Repeat("a", 300) + ".go"} {
+		w, err := zw.Create(mod.String() + "/" + name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte("module example.com/vars\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	require.NoError(t, f.Close())
+
+	t.Setenv("GOFLAGS", "")
+	t.Setenv("GONOPROXY", "")
+	t.Setenv("GOPRIVATE", "")
+	t.Setenv("GOSUMDB", "off")
+	t.Setenv("GOPROXY", "file://"+filepath.ToSlash(corrupt)+"|"+proxy)
+	m, err := download(t, mod, nil)
+	require.NoError(t, err)
+	require.Contains(t, m.Packages, "test_vars")
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	mod := module.Version{Path: "example.com/vars", Version: "v1.0.0"}
+	proxy, h := fileProxy(t, mod, filepath.Join("testdata", "test_vars"))
+	bad := "h1:" + base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+
+	// a stand-in for sum.golang.org whose hash for mod is served by sumDBHash
+	sumDBHash := h
+	signer, verifier, err := note.GenerateKey(rand.Reader, "sumdb.example.com")
+	require.NoError(t, err)
+	srv := httptest.NewServer(sumdb.NewServer(sumdb.NewTestServer(signer, func(path, vers string) ([]byte, error) {
+		return []byte(fmt.Sprintf("%s %s %s\n%s %s/go.mod %s\n", path, vers, sumDBHash, path, vers, bad)), nil
+	})))
+	defer srv.Close()
+
+	t.Setenv("GOFLAGS", "")
+	t.Setenv("GONOPROXY", "")
+	t.Setenv("GONOSUMDB", "")
+	t.Setenv("GOPRIVATE", "")
+	t.Setenv("GOSUMDB", verifier+" "+srv.URL)
+
+	t.Run("go.sum", func(t *testing.T) {
+		t.Setenv("GOPROXY", proxy)
+		m, err := download(t, mod, goSum{mod: h})
+		require.NoError(t, err)
+		require.Contains(t, m.Packages, "test_vars")
+
+		// a mismatch is an error even when GOPROXY lists another proxy having the module
+		t.Setenv("GOPROXY", proxy+"|"+proxy)
+		_, err = download(t, mod, goSum{mod: bad})
+		require.ErrorIs(t, err, errChecksumMismatch)
+		require.ErrorContains(t, err, "go.sum has "+bad)
+	})
+
+	t.Run("sumdb", func(t *testing.T) {
+		t.Setenv("GOPROXY", proxy)
+		m, err := download(t, mod, goSum{})
+		require.NoError(t, err)
+		require.Contains(t, m.Packages, "test_vars")
+
+		// the database's hash for another version of the module doesn't match
+		sumDBHash = bad
+		other := module.Version{Path: mod.Path, Version: "v1.0.1"}
+		otherProxy, _ := fileProxy(t, other, filepath.Join("testdata", "test_vars"))
+		t.Setenv("GOPROXY", otherProxy)
+		_, err = download(t, other, goSum{})
+		require.ErrorIs(t, err, errChecksumMismatch)
+		require.ErrorContains(t, err, "checksum database "+srv.URL+" has "+bad)
+
+		// the database doesn't apply to modules matching GONOSUMDB
+		t.Setenv("GONOSUMDB", "example.com")
+		_, err = download(t, other, goSum{})
+		require.NoError(t, err)
+	})
+}
+
+func TestReadGoSum(t *testing.T) {
+	dir := t.TempDir()
+	sums, err := readGoSum(dir)
+	require.NoError(t, err)
+	require.Empty(t, sums)
+
+	content := "example.com/a v1.0.0 h1:zip=\nexample.com/a v1.0.0/go.mod h1:mod=\n\nexample.com/b v0.1.0 h1:b=\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.sum"), []byte(content), 0644))
+	sums, err = readGoSum(dir)
+	require.NoError(t, err)
+	require.Equal(t, goSum{
+		{Path: "example.com/a", Version: "v1.0.0"}: "h1:zip=",
+		{Path: "example.com/b", Version: "v0.1.0"}: "h1:b=",
+	}, sums)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.sum"), []byte("example.com/a v1.0.0\n"), 0644))
+	_, err = readGoSum(dir)
+	require.ErrorContains(t, err, "go.sum:1: malformed line")
+}
+
+func TestFileURLPath(t *testing.T) {
+	for _, test := range []struct {
+		url, expected string
+		windows       bool
+		err           bool
+	}{
+		{url: "file:///srv/proxy", expected: "/srv/proxy"},
+		{url: "file://localhost/srv/proxy", expected: "/srv/proxy"},
+		{url: "file://server/srv/proxy", err: true},
+		{url: "file:///C:/proxy", windows: true, expected: `C:\proxy`},
+		{url: "file://localhost/c:/go/proxy", windows: true, expected: `c:\go\proxy`},
+		{url: "file://server/share/proxy", windows: true, expected: `\\server\share\proxy`},
+		{url: "file:///proxy", windows: true, err: true},
+		{url: "file:///C:proxy", windows: true, err: true},
+		{url: "file://C:/proxy", windows: true, err: true},
+		{url: "file:proxy", err: true},
+	} {
+		u, err := url.Parse(test.url)
+		require.NoError(t, err)
+		p, err := fileURLPath(u, test.windows)
+		if test.err {
+			require.Error(t, err, test.url)
+			continue
+		}
+		require.NoError(t, err, test.url)
+		require.Equal(t, test.expected, p, test.url)
+	}
+}
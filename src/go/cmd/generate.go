@@ -0,0 +1,195 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/semver"
+)
+
+// generateCmd represents the generate command
+var generateCmd = &cobra.Command{
+	Use:   "generate <moduleDir> [outputDir]",
+	Short: "Write a module's review in APIView format",
+	Long: `generate writes a file representing the public API of an Azure SDK for Go module in
+APIView format. It writes this file to <outputDir>/<module name>.json, overwriting any file of
+the same name, or to the file given by --output. It exits with code 4 when the review has
+fatal diagnostics, for example breaking changes found by --baseline.
+
+--format markdown and --format text write the review as Go-like text instead, with diagnostics
+as comments, and name the file <module name>.md or <module name>.txt. Commit such a file with
+the module so that pull requests show API changes in their diffs. --format html writes a
+standalone page <module name>.html, which needs no network access to view.`,
+	Args:    usageArgs(cobra.RangeArgs(1, 2)),
+	PreRunE: validateGenerateArgs,
+	RunE:    runGenerate,
+}
+
+// compact omits indentation from generated reviews
+var compact bool
+
+// format is the format of the reviews generateCmd and batchCmd write: "html", "json", "markdown" or "text"
+var format string
+
+// output is the path of the file generateCmd writes, or "-" for stdout
+var output string
+
+func init() {
+	addGenerateFlags(generateCmd)
+	rootCmd.AddCommand(generateCmd)
+}
+
+// addGenerateFlags adds the flags of generateCmd to cmd
+func addGenerateFlags(cmd *cobra.Command) {
+	addReviewFlags(cmd)
+	cmd.Flags().StringVar(&baselineDir, "baseline", "", "previous version of the module to check for breaking changes")
+	cmd.Flags().BoolVar(&compact, "compact", false, "write the review without indentation")
+	cmd.Flags().StringVar(&format, "format", "json", `format of the review: "json" for APIView, "html" for a standalone page, or "markdown" or "text" for reading and diffing`)
+	cmd.Flags().StringVarP(&output, "output", "o", "", `write the review to this file, or stdout when "-", instead of <outputDir>`)
+	cmd.Flags().BoolVar(&skipDiffTags, "skip-diff-tags", false, "exclude struct field tags from APIView's diffs of reviews")
+}
+
+// validateGenerateArgs returns a usage error when args and flags don't describe one module and one destination
+func validateGenerateArgs(cmd *cobra.Command, args []string) error {
+	switch {
+	case len(args) == 0 || len(args) > 2:
+		return usageError(fmt.Errorf("expected <moduleDir> and <outputDir>, got %d argument(s)", len(args)))
+	case len(args) == 1 && output == "":
+		return usageError(errors.New("expected <outputDir> or --output"))
+	case len(args) == 2 && output != "":
+		return usageError(errors.New("<outputDir> and --output are mutually exclusive"))
+	}
+	if err := validateFormat(); err != nil {
+		return err
+	}
+	return validateReviewArgs(args[0])
+}
+
+// validateReviewArgs returns a usage error when dir isn't a directory or the review flags are invalid
+func validateReviewArgs(dir string) error {
+	if packageVersion != "" && !semver.IsValid(packageVersion) {
+		return usageError(fmt.Errorf("invalid --version %q: must be a semantic version such as v1.2.3", packageVersion))
+	}
+	return validateModuleDir(dir)
+}
+
+// validateModuleDir returns a usage error when dir isn't a directory
+func validateModuleDir(dir string) error {
+	if fi, err := os.Stat(dir); err != nil {
+		return usageError(err)
+	} else if !fi.IsDir() {
+		return usageError(fmt.Errorf("%s isn't a directory", dir))
+	}
+	return nil
+}
+
+func runGenerate(cmd *cobra.Command, args []string) error {
+	review, err := generateReview(args[0])
+	if err != nil {
+		return err
+	}
+	dest := output
+	if dest == "" {
+		dest = filepath.Join(args[1], reviewFileName(review))
+	}
+	if dest == "-" {
+		err = writeReview(cmd.OutOrStdout(), review)
+	} else if err = writeReviewFile(dest, review); err == nil {
+		logger.Info("wrote review", "path", dest)
+	}
+	if err != nil {
+		return err
+	}
+	return fatalDiagnosticsError(review, CodeDiagnosticLevelFatal)
+}
+
+// generateReview returns a review of the module in dir according to the review flags e.g. --version
+func generateReview(dir string) (CodeFile, error) {
+	r, err := newReview(dir, newModuleIndex())
+	if err != nil {
+		return CodeFile{}, parseError(err)
+	}
+	r.baseline, r.version = baselineDir, packageVersion
+	review, err := r.Review()
+	if err != nil {
+		return CodeFile{}, parseError(err)
+	}
+	if reviewName != "" {
+		review.PackageName = reviewName
+	}
+	return review, nil
+}
+
+// validateFormat returns a usage error when --format is unknown
+func validateFormat() error {
+	if _, ok := formatExtensions[format]; !ok {
+		return usageError(fmt.Errorf(`unknown --format %q: must be "html", "json", "markdown" or "text"`, format))
+	}
+	return nil
+}
+
+// reviewFileName returns the name of the file to which to write review in the format given by --format
+// e.g. "azcore.json"
+func reviewFileName(review CodeFile) string {
+	return review.Name + formatExtensions[format]
+}
+
+// writeReview writes review to w in the format given by --format. JSON is indented unless --compact is set.
+func writeReview(w io.Writer, review CodeFile) error {
+	switch format {
+	case "html":
+		return renderHTML(w, review)
+	case "markdown":
+		return renderMarkdown(w, review)
+	case "text":
+		return renderText(w, review)
+	}
+	var b []byte
+	var err error
+	if compact {
+		b, err = json.Marshal(review)
+	} else {
+		b, err = json.MarshalIndent(review, "", " ")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal review: %w", err)
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// writeReviewFile writes review to the file at path, overwriting any existing file
+func writeReviewFile(path string, review CodeFile) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = writeReview(f, review); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// fatalDiagnosticsError returns an error having exit code exitFatal when review has diagnostics
+// at or above the given level
+func fatalDiagnosticsError(review CodeFile, level CodeDiagnosticLevel) error {
+	n := 0
+	for _, d := range review.Diagnostics {
+		if d.Level >= level {
+			n++
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	return &codeError{code: exitFatal, err: fmt.Errorf("review of %s has %d diagnostic(s) at level %s or above", review.PackageName, n, level)}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/mod/module"
+)
+
+// defaultGOPROXY is the go command's default value for GOPROXY
+const defaultGOPROXY = "https://proxy.golang.org,direct"
+
+// errNotFound indicates a module proxy doesn't have a module. The go command tries the next proxy in
+// GOPROXY after such errors even when the proxies are separated by a comma.
+var errNotFound = errors.New("module not found")
+
+// moduleProxy is an element of GOPROXY
+type moduleProxy struct {
+	// url is the proxy's URL e.g. "https://proxy.golang.org" or "file:///srv/goproxy", or
+	// one of the special values "direct" and "off"
+	url string
+	// fallBackOnError indicates whether to try the next proxy after any error, as when the proxies
+	// are separated by "|", rather than only after errNotFound, as when they're separated by ","
+	fallBackOnError bool
+}
+
+// goEnv returns the values of the given go environment variables. Those come from the go command,
+// which accounts for values set by "go env -w", or from the process environment when the go command
+// isn't available.
+func goEnv(keys ...string) map[string]string {
+	env := map[string]string{}
+	if b, err := exec.Command("go", append([]string{"env", "-json"}, keys...)...).Output(); err == nil {
+		if err = json.Unmarshal(b, &env); err == nil {
+			return env
+		}
+	}
+	for _, k := range keys {
+		env[k] = os.Getenv(k)
+	}
+	return env
+}
+
+// moduleProxies returns the proxies to try, in order, when downloading the module at modPath according
+// to env, the values of GOPROXY, GONOPROXY and GOPRIVATE. Modules matching GONOPROXY, or GOPRIVATE when
+// GONOPROXY isn't set, bypass proxies. As with the go command, proxies listed after "direct" or "off"
+// are never tried.
+func moduleProxies(modPath string, env map[string]string) []moduleProxy {
+	noProxy := env["GONOPROXY"]
+	if noProxy == "" {
+		noProxy = env["GOPRIVATE"]
+	}
+	if module.MatchPrefixPatterns(noProxy, modPath) {
+		return []moduleProxy{{url: "direct"}}
+	}
+	goproxy := env["GOPROXY"]
+	if goproxy == "" {
+		goproxy = defaultGOPROXY
+	}
+	proxies := []moduleProxy{}
+	for goproxy != "" {
+		end := strings.IndexAny(goproxy, ",|")
+		p := moduleProxy{url: goproxy}
+		if end >= 0 {
+			p = moduleProxy{url: goproxy[:end], fallBackOnError: goproxy[end] == '|'}
+			goproxy = goproxy[end+1:]
+		} else {
+			goproxy = ""
+		}
+		p.url = strings.TrimSpace(p.url)
+		switch {
+		case p.url == "":
+			continue
+		case p.url == "direct" || p.url == "off":
+			return append(proxies, p)
+		case !strings.Contains(p.url, "://"):
+			// the go command assumes https for URLs without a scheme
+			p.url = "https://" + p.url
+		}
+		proxies = append(proxies, p)
+	}
+	return proxies
+}
@@ -0,0 +1,232 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// renderHTML writes the review to w as a self-contained HTML page, which works offline because it has
+// no scripts and no external resources. The page colors tokens by kind, links type names to their
+// definitions, shows diagnostics as badges on their lines and has a collapsible tree of the review's
+// Navigation. Hidden lines appear only when the reader checks "Show hidden lines".
+func renderHTML(w io.Writer, review CodeFile) error {
+	report := htmlReport{
+		Name:        review.Name,
+		PackageName: review.PackageName,
+		Version:     review.PackageVersion,
+		Navigation:  review.Navigation,
+	}
+	ids := map[string]bool{}
+	forAll(review.ReviewLines, func(ln ReviewLine) {
+		if ln.LineID != "" {
+			ids[ln.LineID] = true
+		}
+	})
+	diagnostics := map[string][]CodeDiagnostic{}
+	counts := map[CodeDiagnosticLevel]int{}
+	for _, d := range review.Diagnostics {
+		counts[d.Level]++
+		if ids[d.TargetID] {
+			diagnostics[d.TargetID] = append(diagnostics[d.TargetID], d)
+		} else {
+			report.Untargeted = append(report.Untargeted, d)
+		}
+	}
+	for _, l := range []CodeDiagnosticLevel{CodeDiagnosticLevelFatal, CodeDiagnosticLevelError, CodeDiagnosticLevelWarning, CodeDiagnosticLevelInfo} {
+		if n := counts[l]; n > 0 {
+			report.Counts = append(report.Counts, htmlCount{Level: l, N: n})
+		}
+	}
+	report.addLines(review.ReviewLines, 0, false, diagnostics)
+	return htmlTemplate.Execute(w, report)
+}
+
+// htmlReport is the data of htmlTemplate
+type htmlReport struct {
+	Name        string
+	PackageName string
+	Version     string
+	Navigation  []NavigationItem
+	Counts      []htmlCount
+	Lines       []htmlLine
+	// Untargeted are the diagnostics whose targets aren't in the review
+	Untargeted []CodeDiagnostic
+}
+
+// htmlCount is the number of a review's diagnostics at a level
+type htmlCount struct {
+	Level CodeDiagnosticLevel
+	N     int
+}
+
+// htmlLine is a line of an htmlReport
+type htmlLine struct {
+	// ID is the line's LineID
+	ID string
+	// Indent is the line's indentation
+	Indent      string
+	Tokens      []htmlToken
+	Diagnostics []CodeDiagnostic
+	Hidden      bool
+	// Separator indicates the line separates packages
+	Separator bool
+}
+
+// htmlToken is a token of an htmlLine
+type htmlToken struct {
+	// Class is the token's CSS classes
+	Class string
+	// Link is the LineID of the line to which the token navigates
+	Link  string
+	Space bool
+	Value string
+}
+
+// addLines adds lines and their children to the report, indenting them as renderText does. hidden
+// indicates the lines' parent is hidden, which hides the lines too.
+func (r *htmlReport) addLines(lines []ReviewLine, level int, hidden bool, diagnostics map[string][]CodeDiagnostic) {
+	indent := strings.Repeat("\t", max(level-1, 0))
+	for _, ln := range lines {
+		hl := htmlLine{ID: ln.LineID, Indent: indent, Diagnostics: diagnostics[ln.LineID], Hidden: hidden || ln.IsHidden, Separator: isPackageSeparator(ln)}
+		if !hl.Separator {
+			lineTokens(ln, nil, func(tk ReviewToken, space bool) {
+				hl.Tokens = append(hl.Tokens, htmlToken{Class: tokenClass(tk), Link: tk.NavigateToID, Space: space, Value: tk.Value})
+			})
+		}
+		// collapse consecutive blank lines, which APIView shows as one
+		blank := len(hl.Tokens) == 0 && len(hl.Diagnostics) == 0 && !hl.Separator
+		if n := len(r.Lines); !blank || n == 0 || !r.Lines[n-1].isBlank() {
+			r.Lines = append(r.Lines, hl)
+		}
+		r.addLines(ln.Children, level+1, hl.Hidden, diagnostics)
+	}
+}
+
+func (l htmlLine) isBlank() bool {
+	return len(l.Tokens) == 0 && len(l.Diagnostics) == 0 && !l.Separator
+}
+
+// tokenKindClasses maps token kinds to the CSS classes coloring them
+var tokenKindClasses = map[TokenKind]string{
+	TokenKindComment:       "comment",
+	TokenKindKeyword:       "keyword",
+	TokenKindLiteral:       "literal",
+	TokenKindMemberName:    "member",
+	TokenKindPunctuation:   "punctuation",
+	TokenKindStringLiteral: "string",
+	TokenKindText:          "text",
+	TokenKindTypeName:      "type",
+}
+
+// tokenClass returns the CSS classes of tk, which are its kind's class, its RenderClasses and
+// classes marking deprecated and documentation tokens
+func tokenClass(tk ReviewToken) string {
+	classes := []string{tokenKindClasses[tk.Kind]}
+	if tk.IsDeprecated {
+		classes = append(classes, "deprecated")
+	}
+	if tk.IsDocumentation {
+		classes = append(classes, "doc")
+	}
+	classes = append(classes, tk.RenderClasses...)
+	return strings.TrimSpace(strings.Join(classes, " "))
+}
+
+// anchor returns the HTML id of the line having the given LineID. HTML ids can't contain whitespace,
+// which LineIDs such as "azcore-(c *Client) Do" do, so anchor escapes characters other than letters,
+// digits and "._-" as "~" followed by six hex digits, enough for any rune, so that escapes of different
+// runes can't run together ambiguously. That includes "/", which html/template would otherwise escape
+// differently in links.
+func anchor(lineID string) string {
+	sb := strings.Builder{}
+	sb.WriteString("L-")
+	for _, r := range lineID {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', strings.ContainsRune("._-", r):
+			sb.WriteRune(r)
+		default:
+			fmt.Fprintf(&sb, "~%06x", r)
+		}
+	}
+	return sb.String()
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"anchor":     anchor,
+	"diagnostic": diagnosticText,
+	"lower":      func(l CodeDiagnosticLevel) string { return strings.ToLower(l.String()) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Name}}{{with .Version}} {{.}}{{end}}</title>
+<style>
+body { margin: 0; font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2328; background: #fff; }
+header { padding: 12px 20px; border-bottom: 1px solid #d0d7de; }
+header h1 { margin: 0 0 4px; font-size: 20px; }
+header .meta { color: #59636e; font-size: 13px; }
+.layout { display: grid; grid-template-columns: minmax(200px, 22%) 1fr; }
+nav { position: sticky; top: 0; align-self: start; max-height: 100vh; overflow: auto; padding: 12px; border-right: 1px solid #d0d7de; font-size: 13px; }
+nav ul { list-style: none; margin: 0; padding-left: 14px; }
+nav > ul { padding-left: 0; }
+nav summary { cursor: pointer; }
+nav a { color: inherit; text-decoration: none; }
+nav a:hover { text-decoration: underline; }
+main { padding: 12px 20px; overflow-x: auto; }
+.code { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-size: 13px; line-height: 1.5; tab-size: 4; }
+.line { white-space: pre; min-height: 1.5em; }
+.line:target { background: #fff8c5; }
+.line.hidden { display: none; }
+#show-hidden:checked ~ .layout .line.hidden { display: block; opacity: 0.6; }
+hr { border: 0; border-top: 1px solid #d0d7de; margin: 12px 0; }
+.keyword { color: #cf222e; }
+.type { color: #8250df; }
+.member { color: #0550ae; }
+.string { color: #0a3069; }
+.literal { color: #0550ae; }
+.comment, .doc { color: #59636e; }
+.punctuation, .text { color: #1f2328; }
+.deprecated { text-decoration: line-through; }
+.code a { color: inherit; text-decoration: underline dotted; }
+.badge { display: inline-block; margin-left: 8px; padding: 0 6px; border-radius: 10px; font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; font-size: 12px; white-space: normal; }
+.badge.info { background: #ddf4ff; color: #0969da; }
+.badge.warning { background: #fff8c5; color: #7d4e00; }
+.badge.error, .badge.fatal { background: #ffebe9; color: #cf222e; }
+.badge.fatal { font-weight: bold; }
+.summary { margin: 0 0 12px; }
+.summary ul { margin: 4px 0; }
+label { font-size: 13px; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Name}}</h1>
+<div class="meta">{{with .PackageName}}{{.}}{{end}}{{with .Version}} · {{.}}{{end}}{{range .Counts}} <span class="badge {{lower .Level}}">{{.N}} {{.Level}}</span>{{end}}</div>
+</header>
+<input type="checkbox" id="show-hidden"> <label for="show-hidden">Show hidden lines</label>
+<div class="layout">
+<nav>
+{{template "nav" .Navigation}}
+</nav>
+<main>
+{{with .Untargeted}}<div class="summary">Diagnostics without a line in this review:
+<ul>{{range .}}<li><span class="badge {{lower .Level}}">{{diagnostic .}}</span> <code>{{.TargetID}}</code></li>{{end}}</ul>
+</div>{{end}}
+<div class="code">
+{{range .Lines}}{{if .Separator}}<hr>
+{{else}}<div class="line{{if .Hidden}} hidden{{end}}"{{with .ID}} id="{{anchor .}}"{{end}}>{{.Indent}}{{range .Tokens}}{{if .Space}} {{end}}{{if .Link}}<a href="#{{anchor .Link}}" class="{{.Class}}">{{.Value}}</a>{{else}}<span class="{{.Class}}">{{.Value}}</span>{{end}}{{end}}{{range .Diagnostics}}<span class="badge {{lower .Level}}">{{diagnostic .}}</span>{{end}}</div>
+{{end}}{{end}}</div>
+</main>
+</div>
+</body>
+</html>
+{{define "nav"}}{{with .}}<ul>
+{{range .}}{{if .ChildItems}}<li><details open><summary><a href="#{{anchor .NavigationID}}">{{.Text}}</a></summary>{{template "nav" .ChildItems}}</details></li>
+{{else}}<li><a href="#{{anchor .NavigationID}}">{{.Text}}</a></li>
+{{end}}{{end}}</ul>{{end}}{{end}}`))
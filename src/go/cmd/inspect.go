@@ -0,0 +1,82 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// inspectCmd represents the inspect command
+var inspectCmd = &cobra.Command{
+	Use:   "inspect <moduleDir>",
+	Short: "Summarize what apiviewgo indexes in a module",
+	Long: `inspect indexes an Azure SDK for Go module and writes a summary to stdout: the module's path,
+review name and version, and the number of exported declarations of each kind in each package.
+It's useful for checking which packages and build constraints apiviewgo sees before generating a
+review.`,
+	Args: usageArgs(cobra.ExactArgs(1)),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateReviewArgs(args[0])
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := NewReview(args[0])
+		if err != nil {
+			return parseError(err)
+		}
+		name, version := r.name, r.reviewed.Version
+		if reviewName != "" {
+			name = reviewName
+		}
+		if packageVersion != "" {
+			version = packageVersion
+		}
+		if version == "" {
+			version = "(unknown)"
+		}
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "Module:\t%s\n", r.reviewed.ModFile.Module.Mod.Path)
+		fmt.Fprintf(w, "Review:\t%s\n", name)
+		fmt.Fprintf(w, "Version:\t%s\n", version)
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "PACKAGE\tCONSTS\tFUNCS\tINTERFACES\tSTRUCTS\tTYPES\tVARS\tDIAGNOSTICS")
+		pkgs := make([]*Pkg, 0, len(r.reviewed.Packages))
+		for _, p := range r.reviewed.Packages {
+			pkgs = append(pkgs, p)
+		}
+		sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Name() < pkgs[j].Name() })
+		for _, p := range pkgs {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\n",
+				p.Name(),
+				countExported(p.c.Consts),
+				countExported(p.c.Funcs),
+				countExported(p.c.Interfaces),
+				countExported(p.c.Structs),
+				countExported(p.c.SimpleTypes),
+				countExported(p.c.Vars),
+				len(p.diagnostics),
+			)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	addReviewFlags(inspectCmd)
+	rootCmd.AddCommand(inspectCmd)
+}
+
+// countExported returns the number of exported declarations in m
+func countExported[T TokenMaker](m map[string]T) int {
+	n := 0
+	for _, t := range m {
+		if t.Exported() {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,68 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint <moduleDir>",
+	Short: "Report the diagnostics of a module's review",
+	Long: `lint reviews an Azure SDK for Go module and writes the review's diagnostics to stdout, one
+per line. It exits with code 4 when any diagnostic is at the level given by --fail-on or above.`,
+	Args: usageArgs(cobra.ExactArgs(1)),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := parseLevel(failOn); err != nil {
+			return usageError(err)
+		}
+		return validateReviewArgs(args[0])
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		review, err := generateReview(args[0])
+		if err != nil {
+			return err
+		}
+		sb := strings.Builder{}
+		for _, d := range review.Diagnostics {
+			fmt.Fprintf(&sb, "%s: %s: %s", d.Level, d.TargetID, d.Text)
+			if d.DiagnosticID != "" {
+				fmt.Fprintf(&sb, " (%s)", d.DiagnosticID)
+			}
+			sb.WriteString("\n")
+		}
+		if sb.Len() == 0 {
+			sb.WriteString("No diagnostics\n")
+		}
+		if _, err = cmd.OutOrStdout().Write([]byte(sb.String())); err != nil {
+			return err
+		}
+		level, _ := parseLevel(failOn)
+		return fatalDiagnosticsError(review, level)
+	},
+}
+
+// failOn is the name of the lowest diagnostic level failing lintCmd
+var failOn string
+
+func init() {
+	addReviewFlags(lintCmd)
+	lintCmd.Flags().StringVar(&baselineDir, "baseline", "", "previous version of the module to check for breaking changes")
+	lintCmd.Flags().StringVar(&failOn, "fail-on", "error", "exit with code 4 when a diagnostic has this level or above: info, warning, error or fatal")
+	rootCmd.AddCommand(lintCmd)
+}
+
+// parseLevel returns the diagnostic level having the given case-insensitive name e.g. "warning"
+func parseLevel(name string) (CodeDiagnosticLevel, error) {
+	for _, l := range []CodeDiagnosticLevel{CodeDiagnosticLevelInfo, CodeDiagnosticLevelWarning, CodeDiagnosticLevelError, CodeDiagnosticLevelFatal} {
+		if strings.EqualFold(name, l.String()) {
+			return l, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown diagnostic level %q", name)
+}
@@ -0,0 +1,95 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/exp/slices"
+)
+
+// logger writes messages about apiviewgo's progress and problems to stderr. Commands replace it
+// according to --log-format, --quiet and --verbose.
+var logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+// logFormat is the format of log messages, "text" or "json"
+var logFormat string
+
+// parserDiagnostics adds a Warning diagnostic to reviews for each parser warning
+var parserDiagnostics bool
+
+// verbose enables debug messages, including each parser warning
+var verbose bool
+
+// newLogger returns a logger writing to w according to --log-format, --quiet and --verbose
+func newLogger(w io.Writer) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: slog.LevelInfo}
+	switch {
+	case quiet && verbose:
+		return nil, errors.New("--quiet and --verbose are mutually exclusive")
+	case quiet:
+		opts.Level = slog.LevelWarn
+	case verbose:
+		opts.Level = slog.LevelDebug
+	}
+	switch logFormat {
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf(`invalid --log-format %q: must be "json" or "text"`, logFormat)
+	}
+}
+
+// parseWarning describes source code apiviewgo couldn't fully describe in a review
+type parseWarning struct {
+	// pos is the position of the code e.g. "client.go:42"
+	pos string
+	// source is the code's text
+	source string
+	// targetID is the LineID of the review line nearest the code, for diagnostics
+	targetID string
+	text     string
+}
+
+// parseWarnings collects a package's parser warnings. Pkg has a pointer to it so that copies of
+// the Pkg, which methods having value receivers get, record warnings in the same place.
+type parseWarnings struct {
+	list []parseWarning
+}
+
+// warn records a parser warning about n, which is nearest the review line identified by targetID,
+// and logs it at debug level. Indexing logs a summary of all the warnings at warning level.
+func (pkg Pkg) warn(targetID string, n ast.Node, text string) {
+	pos := pkg.fs.Position(n.Pos())
+	w := parseWarning{
+		pos:      fmt.Sprintf("%s:%d", filepath.Base(pos.Filename), pos.Line),
+		source:   pkg.getText(n.Pos(), n.End()),
+		targetID: targetID,
+		text:     text,
+	}
+	if slices.Contains(pkg.warnings.list, w) {
+		// indexing may visit the same code more than once
+		return
+	}
+	pkg.warnings.list = append(pkg.warnings.list, w)
+	logger.Debug(text, "package", pkg.Name(), "pos", w.pos, "source", w.source)
+}
+
+// diagnostic returns a Warning diagnostic describing w
+func (w parseWarning) diagnostic() CodeDiagnostic {
+	return CodeDiagnostic{
+		DiagnosticID: parserWarningID,
+		Level:        CodeDiagnosticLevelWarning,
+		TargetID:     w.targetID,
+		Text:         fmt.Sprintf("%s%s (%s: %s)", parserWarning, w.text, w.pos, w.source),
+	}
+}
@@ -3,7 +3,10 @@
 
 package cmd
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 // This file contains models comprising an APIView document
 
@@ -28,6 +31,22 @@ const (
 	CodeDiagnosticLevelFatal CodeDiagnosticLevel = 4
 )
 
+// String returns the level's name e.g. "Warning"
+func (l CodeDiagnosticLevel) String() string {
+	switch l {
+	case CodeDiagnosticLevelInfo:
+		return "Info"
+	case CodeDiagnosticLevelWarning:
+		return "Warning"
+	case CodeDiagnosticLevelError:
+		return "Error"
+	case CodeDiagnosticLevelFatal:
+		return "Fatal"
+	default:
+		return fmt.Sprintf("CodeDiagnosticLevel(%d)", int(l))
+	}
+}
+
 type CodeFile struct {
 	CrossLanguagePackageID string           `json:"CrossLanguagePackageId,omitempty"`
 	Diagnostics            []CodeDiagnostic `json:"Diagnostics,omitempty"`
@@ -7,13 +7,18 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
+	"go/build"
 	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode"
 
+	"golang.org/x/exp/slices"
 	"golang.org/x/mod/modfile"
 	"golang.org/x/mod/module"
 )
@@ -35,6 +40,30 @@ type Module struct {
 	Name string
 	// Packages maps import paths to the module's Packages
 	Packages map[string]*Pkg
+	// Version of the module e.g. "v1.2.3". It's empty when the version is unknown.
+	Version string
+}
+
+// replacing returns a view of m as the replacement of the module at modPath. The view has modPath as its
+// module path, and its Packages map import paths beginning with modPath, as well as m's own import paths
+// used by m's source, to m's packages. m is unchanged, so it may be shared with other reviews.
+func (m *Module) replacing(modPath string) *Module {
+	own := m.ModFile.Module.Mod.Path
+	if own == modPath {
+		return m
+	}
+	mf := *m.ModFile
+	mf.Module = &modfile.Module{Mod: module.Version{Path: modPath}}
+	view := *m
+	view.ModFile = &mf
+	view.Packages = make(map[string]*Pkg, 2*len(m.Packages))
+	for impPath, p := range m.Packages {
+		view.Packages[impPath] = p
+		if after, ok := strings.CutPrefix(p.importPath, own); ok && (after == "" || after[0] == '/') {
+			view.Packages[modPath+after] = p
+		}
+	}
+	return &view
 }
 
 // getPackageNameFromModPath gets the API review name for the module at modPath
@@ -52,22 +81,32 @@ func getPackageNameFromModPath(modPath string) string {
 	return modPath
 }
 
-// NewModule indexes a module's ASTs
+// NewModule indexes a module's ASTs. It indexes the files matching the build context given by the
+// --goos, --goarch and --tags flags or, when --platforms is set, the union of the given platforms.
 func NewModule(dir string) (*Module, error) {
-	fmt.Println("Indexing", dir)
+	if len(platforms) > 0 {
+		return newPlatformsModule(dir, platforms)
+	}
+	return newModule(dir, buildContext(goos, goarch))
+}
+
+// newModule indexes the ASTs of a module's files matching ctx
+func newModule(dir string, ctx *build.Context) (*Module, error) {
+	logger.Info("indexing module", "dir", dir, "goos", ctx.GOOS, "goarch", ctx.GOARCH)
 	mf, err := parseModFile(dir)
 	if err != nil {
 		return nil, err
 	}
-	name := filepath.Base(dir)
-	if before, _, found := strings.Cut(name, "@"); found {
+	name, version := filepath.Base(dir), ""
+	if before, after, found := strings.Cut(name, "@"); found {
 		// dir is in the module cache, something like "/home/me/go/pkg/mod/github.com/Foo/bar@v1.0.0"
-		name = before
+		name, version = before, after
 	}
 	m := Module{
 		ModFile:  mf,
 		Name:     name,
 		Packages: map[string]*Pkg{},
+		Version:  version,
 	}
 
 	baseImportPath := path.Dir(m.ModFile.Module.Mod.Path) + "/"
@@ -78,18 +117,15 @@ func NewModule(dir string) (*Module, error) {
 	}
 	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
 		if d.IsDir() {
-			if !indexTestdata && strings.Contains(path, "testdata") {
+			if skipTestdata(path) {
 				return filepath.SkipDir
 			}
-			if path != dir {
-				// This is a subdirectory of the module we're indexing. If it contains
-				// a go.mod, this subdirectory contains a separate module, not a package
-				// of the module we're indexing.
-				if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
-					return filepath.SkipDir
-				}
+			if path != dir && isModuleRoot(path) {
+				// This is a subdirectory of the module we're indexing. It contains
+				// a separate module, not a package of the module we're indexing.
+				return filepath.SkipDir
 			}
-			p, err := NewPkg(path, m.ModFile.Module.Mod.Path, dir)
+			p, err := NewPkg(path, m.ModFile.Module.Mod.Path, dir, ctx)
 			if err == nil {
 				m.Packages[baseImportPath+p.Name()] = p
 			} else if !errors.Is(err, ErrNoPackages) {
@@ -102,9 +138,21 @@ func NewModule(dir string) (*Module, error) {
 		return nil, err
 	}
 
+	if typeCheck {
+		m.typeCheck(dir, ctx)
+	}
 	for _, p := range m.Packages {
 		p.Index()
 	}
+	m.inferDeclarationTypes()
+	m.diagnoseConstraintUses()
+	m.diagnoseInternalTypeLeaks()
+	if n := m.warningCount(); n > 0 {
+		logger.Warn("some declarations couldn't be fully described; use --verbose for details", "dir", dir, "warnings", n)
+	}
+	if m.Version == "" {
+		m.Version = m.moduleVersionConst()
+	}
 	// resolve cross-package references by adding the definitions of types exported by alias to the exporting package
 	for _, p := range m.Packages {
 		for _, alias := range p.TypeAliases {
@@ -133,52 +181,438 @@ func NewModule(dir string) (*Module, error) {
 	return &m, nil
 }
 
-// returns the type name for the specified struct field.
-// if the field can be ignored, an empty string is returned.
-func unwrapStructFieldTypeName(field *ast.Field) string {
-	if field.Names != nil && !field.Names[0].IsExported() {
-		// field isn't exported so skip it
-		return ""
+// moduleVersionConst returns the value of the module's moduleVersion const, which by Azure SDK convention is
+// defined in version.go. When more than one package defines the const, the one nearest the module root wins.
+// moduleVersionConst returns an empty string when the module has no such const.
+func (m *Module) moduleVersionConst() string {
+	paths := make([]string, 0, len(m.Packages))
+	for impPath := range m.Packages {
+		paths = append(paths, impPath)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if a, b := strings.Count(paths[i], "/"), strings.Count(paths[j], "/"); a != b {
+			return a < b
+		}
+		return paths[i] < paths[j]
+	})
+	for _, impPath := range paths {
+		if d, ok := m.Packages[impPath].c.Consts["moduleVersion"]; ok {
+			if v, err := strconv.Unquote(d.value); err == nil {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// inferDeclarationTypes sets the types of const and var declarations whose values are the results of calls,
+// such as "var DefaultOptions = NewOptions()" and "const FooBar = Foo("bar")", so that reviews group these
+// declarations with their types. The called func or converted type may be in any package of the module.
+func (m *Module) inferDeclarationTypes() {
+	byImportPath := map[string]*Pkg{}
+	for _, p := range m.Packages {
+		byImportPath[p.importPath] = p
+	}
+	for _, p := range m.Packages {
+		for _, decls := range []map[string]Declaration{p.c.Consts, p.c.Vars} {
+			for name, d := range decls {
+				if d.call == nil {
+					continue
+				}
+				d.Type = callResultType(p, d, byImportPath)
+				d.call = nil
+				decls[name] = d
+			}
+		}
+	}
+}
+
+// warningCount returns the number of parser warnings in the module's packages
+func (m *Module) warningCount() int {
+	n := 0
+	for _, p := range m.Packages {
+		n += len(p.warnings.list)
+	}
+	return n
+}
+
+// diagnoseConstraintUses adds an Error diagnostic for each exported declaration using an exported constraint
+// interface, such as "type Number interface{ ~int | ~float64 }", outside a type parameter list. The
+// constraint may be in any package of the module.
+func (m *Module) diagnoseConstraintUses() {
+	byImportPath := map[string]*Pkg{}
+	for _, p := range m.Packages {
+		byImportPath[p.importPath] = p
+	}
+	for _, p := range m.Packages {
+		names := make([]string, 0, len(p.p.Files))
+		for name := range p.p.Files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			f := p.p.Files[name]
+			imports := fileImports(f)
+			// constraint returns the text of x when it names an exported constraint interface
+			constraint := func(x ast.Expr) (string, bool) {
+				target, name := p, ""
+				switch t := x.(type) {
+				case *ast.Ident:
+					name = t.Name
+				case *ast.SelectorExpr:
+					pkgName, ok := t.X.(*ast.Ident)
+					if !ok {
+						return "", false
+					}
+					if target, ok = byImportPath[imports[pkgName.Name]]; !ok {
+						return "", false
+					}
+					name = t.Sel.Name
+				default:
+					return "", false
+				}
+				if !unicode.IsUpper(rune(name[0])) || !target.c.isConstraint(name) {
+					return "", false
+				}
+				return p.getText(x.Pos(), x.End()), true
+			}
+			diagnose := func(id string, n ast.Node) {
+				for _, c := range constraintUses(n, constraint) {
+					p.diagnostics = append(p.diagnostics, CodeDiagnostic{
+						DiagnosticID: constraintOutsideTypeParID,
+						Level:        CodeDiagnosticLevelError,
+						TargetID:     id,
+						Text:         constraintOutsideTypeParams + c,
+					})
+				}
+			}
+			for _, decl := range f.Decls {
+				switch d := decl.(type) {
+				case *ast.FuncDecl:
+					if fn := NewFunc(*p, d, imports); fn.Exported() {
+						if d.Recv != nil {
+							diagnose(fn.ID(), d.Recv)
+						}
+						diagnose(fn.ID(), d.Type)
+					}
+				case *ast.GenDecl:
+					for _, spec := range d.Specs {
+						switch s := spec.(type) {
+						case *ast.TypeSpec:
+							// "type Number = sub.Number" re-exports a constraint, which is fine
+							if _, ok := constraint(s.Type); s.Name.IsExported() && !ok {
+								diagnose(p.Name()+"."+s.Name.Name, s.Type)
+							}
+						case *ast.ValueSpec:
+							for _, n := range s.Names {
+								if n.IsExported() && s.Type != nil {
+									diagnose(p.Name()+"."+n.Name, s.Type)
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// constraintUses returns the text of each constraint interface n refers to outside type parameter lists.
+// constraint returns the text of an identifier or qualified identifier naming a constraint interface.
+func constraintUses(n ast.Node, constraint func(ast.Expr) (string, bool)) []string {
+	uses := []string{}
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.Field:
+			// skip the field's names, which could match a constraint's name
+			uses = append(uses, constraintUses(x.Type, constraint)...)
+			return false
+		case *ast.FuncType:
+			// skip the type parameters
+			for _, fl := range []*ast.FieldList{x.Params, x.Results} {
+				if fl != nil {
+					uses = append(uses, constraintUses(fl, constraint)...)
+				}
+			}
+			return false
+		case *ast.InterfaceType:
+			// an interface may embed a constraint, making it a constraint too
+			for _, m := range x.Methods.List {
+				if len(m.Names) > 0 {
+					uses = append(uses, constraintUses(m.Type, constraint)...)
+				}
+			}
+			return false
+		case *ast.Ident:
+			if c, ok := constraint(x); ok {
+				uses = append(uses, c)
+			}
+		case *ast.SelectorExpr:
+			if c, ok := constraint(x); ok {
+				uses = append(uses, c)
+			}
+			// don't visit the package name
+			return false
+		}
+		return true
+	})
+	return uses
+}
+
+// diagnoseInternalTypeLeaks adds an Error diagnostic for each type declared in an internal package, and
+// referred to by an exported declaration outside internal packages, that no package of the module exports
+// by alias. Applications can't name such a type, for example to declare a variable holding a func's result.
+func (m *Module) diagnoseInternalTypeLeaks() {
+	// aliased are the qualified names of types the module exports by alias
+	aliased := map[string]bool{}
+	for _, p := range m.Packages {
+		if !isInternalPath(p.importPath) {
+			for _, ta := range p.TypeAliases {
+				aliased[ta.QualifiedName] = true
+			}
+		}
+	}
+	for _, p := range m.Packages {
+		if isInternalPath(p.importPath) {
+			continue
+		}
+		names := make([]string, 0, len(p.p.Files))
+		for name := range p.p.Files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			f := p.p.Files[name]
+			imports := fileImports(f)
+			diagnose := func(id string, n ast.Node) {
+				for _, ref := range p.typeRefs(n, imports) {
+					if importPath, _ := splitQualifiedName(ref); !isInternalPath(importPath) || aliased[ref] {
+						continue
+					}
+					// omit the module path from the names of its own types e.g. "internal/exported.Request"
+					if _, after, found := strings.Cut(ref, m.ModFile.Module.Mod.Path+"/"); found {
+						ref = after
+					}
+					p.diagnostics = append(p.diagnostics, CodeDiagnostic{
+						DiagnosticID: exposesInternalTypeID,
+						Level:        CodeDiagnosticLevelError,
+						TargetID:     id,
+						Text:         exposesInternalType + ref,
+					})
+				}
+			}
+			for _, decl := range f.Decls {
+				switch d := decl.(type) {
+				case *ast.FuncDecl:
+					if fn := NewFunc(*p, d, imports); fn.Exported() {
+						diagnose(fn.ID(), d.Type)
+					}
+				case *ast.GenDecl:
+					for _, spec := range d.Specs {
+						switch s := spec.(type) {
+						case *ast.TypeSpec:
+							if !s.Name.IsExported() {
+								continue
+							}
+							id := p.Name() + "." + s.Name.Name
+							switch t := unparen(s.Type).(type) {
+							case *ast.SelectorExpr:
+								// Index treats this as an alias e.g. "type Request = exported.Request"
+							case *ast.InterfaceType:
+								exportedMembers(t.Methods, func(name string, x ast.Expr) { diagnose(memberID(id, name), x) })
+							case *ast.StructType:
+								exportedMembers(t.Fields, func(name string, x ast.Expr) { diagnose(memberID(id, name), x) })
+							default:
+								diagnose(id, s.Type)
+							}
+						case *ast.ValueSpec:
+							for _, n := range s.Names {
+								if n.IsExported() && s.Type != nil {
+									diagnose(p.Name()+"."+n.Name, s.Type)
+								}
+							}
+						}
+					}
+				}
+			}
+		}
 	}
+}
 
-	// start with the field expression
-	exp := field.Type
+// memberID returns the LineID of the field or method having the given name in the struct or interface
+// identified by id, or id when name is empty because the member is embedded
+func memberID(id, name string) string {
+	if name == "" {
+		return id
+	}
+	return id + "-" + name
+}
 
-	// if it's an array, get the element expression.
-	// current codegen doesn't support *[]Type so no need to handle it.
-	if at, ok := exp.(*ast.ArrayType); ok {
-		// FieldName []FieldType
-		// FieldName []*FieldType
-		exp = at.Elt
+// exportedMembers calls fn with the name and type of each exported field or method in fl. The name is empty
+// for embedded types.
+func exportedMembers(fl *ast.FieldList, fn func(name string, x ast.Expr)) {
+	if fl == nil {
+		return
+	}
+	for _, f := range fl.List {
+		if len(f.Names) == 0 {
+			// an embedded type, which is exported when its name is e.g. "*internal.Base" or "Base[T]"
+			x := f.Type
+			if star, ok := x.(*ast.StarExpr); ok {
+				x = star.X
+			}
+			switch t := x.(type) {
+			case *ast.IndexExpr:
+				x = t.X
+			case *ast.IndexListExpr:
+				x = t.X
+			}
+			if sel, ok := x.(*ast.SelectorExpr); ok {
+				x = sel.Sel
+			}
+			if id, ok := x.(*ast.Ident); !ok || id.IsExported() {
+				fn("", f.Type)
+			}
+			continue
+		}
+		for _, n := range f.Names {
+			if n.IsExported() {
+				fn(n.Name, f.Type)
+			}
+		}
 	}
+}
 
-	// from here we either have a pointer-to-type or type
-	var ident *ast.Ident
-	if se, ok := exp.(*ast.StarExpr); ok {
-		// FieldName *FieldType
-		ident, _ = se.X.(*ast.Ident)
-	} else {
-		// FieldName FieldType
-		ident, _ = exp.(*ast.Ident)
+// typeRefs returns the qualified names of the named types n, a node in p, refers to e.g.
+// "github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/exported.Request", in order of appearance
+// and without duplicates. It omits predeclared types and types of packages not in imports.
+func (p *Pkg) typeRefs(n ast.Node, imports map[string]string) []string {
+	refs := []string{}
+	add := func(ref string) {
+		if !slices.Contains(refs, ref) {
+			refs = append(refs, ref)
+		}
 	}
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.Field:
+			// skip the field's names, which could match a type's name
+			for _, ref := range p.typeRefs(x.Type, imports) {
+				add(ref)
+			}
+			return false
+		case *ast.Ident:
+			if _, ok := p.types[x.Name]; ok {
+				add(p.importPath + "." + x.Name)
+			}
+		case *ast.SelectorExpr:
+			if pkgName, ok := x.X.(*ast.Ident); ok {
+				if importPath, ok := imports[pkgName.Name]; ok {
+					add(importPath + "." + x.Sel.Name)
+				}
+			}
+			// don't visit the package name
+			return false
+		}
+		return true
+	})
+	return refs
+}
+
+// splitQualifiedName returns the import path and name of a type's qualified name e.g. "net/http" and "Client"
+// for "net/http.Client"
+func splitQualifiedName(ref string) (string, string) {
+	i := strings.LastIndex(ref, ".")
+	return ref[:i], ref[i+1:]
+}
 
-	// !IsExported() is a hacky way to ignore primitive types
-	// FieldName bool
-	if ident == nil || !ident.IsExported() {
+// isInternalPath returns whether the package having the given import path is internal, meaning only packages
+// rooted at the parent of its "internal" directory can import it
+func isInternalPath(importPath string) bool {
+	return slices.Contains(strings.Split(importPath, "/"), "internal")
+}
+
+// callResultType returns the type of the value of d, a declaration in p whose value is the result of
+// d.call, as that type would appear in p. It returns an empty string when it can't determine the type,
+// for example because the called func is in another module.
+func callResultType(p *Pkg, d Declaration, byImportPath map[string]*Pkg) string {
+	target, name, qualifier := p, "", ""
+	switch fn := d.call.Fun.(type) {
+	case *ast.Ident:
+		// "NewOptions()" or "Foo("bar")"
+		name = fn.Name
+	case *ast.SelectorExpr:
+		// "sub.NewOptions()" or "sub.Foo("bar")"
+		x, ok := fn.X.(*ast.Ident)
+		if !ok {
+			return ""
+		}
+		if target, ok = byImportPath[d.imports[x.Name]]; !ok {
+			return ""
+		}
+		name, qualifier = fn.Sel.Name, x.Name
+	default:
 		return ""
 	}
+	if f, ok := target.c.Funcs[name]; ok {
+		if len(f.typeParams) > 0 || d.result >= len(f.Returns) {
+			return ""
+		}
+		return qualifyTypes(f.Returns[d.result], qualifier)
+	}
+	_, isType := target.types[name]
+	if _, ok := target.c.SimpleTypes[name]; ok || isType {
+		// a conversion
+		return qualifyTypes(fmt.Sprintf("<%s.%s>%s", target.Name(), name, name), qualifier)
+	}
+	if target == p && name != "nil" && slices.Contains(internalTypes, name) {
+		// a conversion to a predeclared type such as "int64(42)"
+		return name
+	}
+	return ""
+}
+
+// navigableTypeRgx matches a type name having a navigation mark e.g. "<azcore.ETag>ETag"
+var navigableTypeRgx = regexp.MustCompile(`<[^>]+>[\w.]+`)
 
-	// returns FieldType
-	return ident.Name
+// qualifyTypes qualifies the unqualified names of navigable types in t, a type translated in
+// another package, so t is correct in a package importing that package as qualifier. It returns
+// t unchanged when qualifier is empty.
+func qualifyTypes(t, qualifier string) string {
+	if qualifier == "" {
+		return t
+	}
+	return navigableTypeRgx.ReplaceAllStringFunc(t, func(s string) string {
+		nav, name, _ := strings.Cut(s, ">")
+		if strings.Contains(name, ".") {
+			return s
+		}
+		return nav + ">" + qualifier + "." + name
+	})
 }
 
 func hoistMethodsForType(pkg *Pkg, typeName string, target *Pkg) {
 	methods := pkg.c.findMethods(typeName)
 	for sig, fn := range methods {
-		target.c.Funcs[sig] = fn.ForAlias(target.Name())
+		clone := fn.ForAlias(target.Name())
+		target.c.Funcs[sig] = clone
+		if clone.Exported() {
+			target.diagnoseDeprecations(clone)
+		}
 	}
 }
 
+// skipTestdata returns whether indexing should skip the directory at path because it's testdata
+func skipTestdata(path string) bool {
+	return !indexTestdata && strings.Contains(path, "testdata")
+}
+
+// isModuleRoot returns whether dir contains a go.mod file, making it the root of a module
+func isModuleRoot(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, "go.mod"))
+	return err == nil
+}
+
 func parseModFile(dir string) (*modfile.File, error) {
 	p := filepath.Join(dir, "go.mod")
 	content, err := os.ReadFile(p)
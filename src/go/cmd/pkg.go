@@ -7,11 +7,14 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
+	"go/build"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"unicode"
 
@@ -21,10 +24,34 @@ import (
 
 // diagnostic messages
 const (
-	aliasFor               = "Alias for "
-	missingAliasFor        = "missing alias for nested type "
-	embedsUnexportedStruct = "Anonymously embeds unexported struct "
-	sealedInterface        = "Applications can't implement this interface"
+	aliasFor                    = "Alias for "
+	missingAliasFor             = "missing alias for nested type "
+	exposesInternalType         = "Exposes internal type without an exported alias: "
+	embedsUnexportedStruct      = "Anonymously embeds unexported struct "
+	sealedInterface             = "Applications can't implement this interface"
+	constraintOutsideTypeParams = "Uses constraint interface outside a type parameter list: "
+	unhandledTypeDefinition     = "Can't describe the definition of type "
+	parserWarning               = "Parser warning: "
+	declaredOnlyFor             = "Declared only for "
+	declaredDifferentlyFor      = "Declared differently for "
+)
+
+// DiagnosticIDs of the diagnostics reviews include regardless of lint rules. Configs refer to them as
+// they do to lint rules, to change their levels or suppress them.
+const (
+	aliasID                    = "alias"
+	breakingChangeID           = "breaking-change"
+	constraintOutsideTypeParID = "constraint-outside-type-params"
+	deprecatedID               = "deprecated"
+	embedsUnexportedStructID   = "embeds-unexported-struct"
+	exposesInternalTypeID      = "exposes-internal-type"
+	missingAliasID             = "missing-alias"
+	parserWarningID            = "parser-warning"
+	platformDifferenceID       = "platform-difference"
+	platformOnlyID             = "platform-only"
+	sealedInterfaceID          = "sealed-interface"
+	unhandledTypeDefinitionID  = "unhandled-type-definition"
+	unusedSuppressionID        = "unused-suppression"
 )
 
 var ErrNoPackages = errors.New("no packages found")
@@ -34,10 +61,15 @@ type Pkg struct {
 	modulePath  string
 	c           content
 	diagnostics []CodeDiagnostic
+	doc         *ast.CommentGroup
 	files       map[string][]byte
 	fs          *token.FileSet
+	importPath  string
+	info        *types.Info
 	p           *ast.Package
 	relName     string
+	// typeTokens has the tokens of the types described from info
+	typeTokens typeTokenTable
 
 	// TypeAliases are types exported from this package but defined in another. For
 	// example, package "azcore" may export TokenCredential from azcore/internal/shared
@@ -48,6 +80,9 @@ type Pkg struct {
 
 	// types maps the name of a type defined in this package to that type's definition
 	types map[string]typeDef
+
+	// warnings are the package's parser warnings
+	warnings *parseWarnings
 }
 
 // NewPkg loads the package in the specified directory.
@@ -55,26 +90,42 @@ type Pkg struct {
 //   - dir is the directory containing the package
 //   - modulePath is the import path of the module containing the package
 //   - moduleRoot is the root directory of the module on disk i.e., the directory containing its go.mod
-func NewPkg(dir, modulePath, moduleRoot string) (*Pkg, error) {
+//   - ctx determines which files belong to the package, according to their build constraints
+func NewPkg(dir, modulePath, moduleRoot string, ctx *build.Context) (*Pkg, error) {
 	pk := &Pkg{
 		modulePath:  modulePath,
 		c:           newContent(),
 		diagnostics: []CodeDiagnostic{},
+		typeTokens:  typeTokenTable{},
 		types:       map[string]typeDef{},
+		warnings:    &parseWarnings{},
 	}
 	modulePathWithoutVersion := strings.TrimSuffix(versionReg.ReplaceAllString(modulePath, "/"), "/")
 	moduleName := filepath.Base(modulePathWithoutVersion)
 	if _, after, found := strings.Cut(dir, moduleRoot); found {
 		pk.relName = strings.ReplaceAll(moduleName+after, "\\", "/")
+		pk.importPath = modulePath + filepath.ToSlash(after)
 	} else {
 		return nil, errors.New(dir + " isn't part of module " + moduleName)
 	}
 	pk.files = map[string][]byte{}
 	pk.fs = token.NewFileSet()
+	var matchErr error
 	packages, err := parser.ParseDir(pk.fs, dir, func(f os.FileInfo) bool {
 		// exclude test files
-		return !strings.HasSuffix(f.Name(), "_test.go")
-	}, 0)
+		if strings.HasSuffix(f.Name(), "_test.go") {
+			return false
+		}
+		// exclude files whose build constraints or name e.g. "foo_windows.go" don't match ctx
+		match, err := ctx.MatchFile(dir, f.Name())
+		if err != nil && matchErr == nil {
+			matchErr = err
+		}
+		return match
+	}, parser.ParseComments)
+	if err == nil {
+		err = matchErr
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -100,13 +151,24 @@ func (pkg Pkg) Name() string {
 
 // Index parses the package's files, adding exported types to the package's content as discovered.
 func (p *Pkg) Index() {
-	for _, f := range p.p.Files {
+	// visit files in a consistent order so the package doc comment is deterministic
+	names := make([]string, 0, len(p.p.Files))
+	for name := range p.p.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f := p.p.Files[name]
+		if p.doc == nil && f.Doc != nil {
+			p.doc = f.Doc
+		}
 		p.indexFile(f)
 	}
 }
 
-func (p *Pkg) indexFile(f *ast.File) {
-	// map import aliases to full import paths e.g. "shared" => "github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/shared"
+// fileImports maps the import aliases of f to full import paths
+// e.g. "shared" => "github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/shared"
+func fileImports(f *ast.File) map[string]string {
 	imports := map[string]string{}
 	for _, imp := range f.Imports {
 		p := strings.Trim(imp.Path.Value, `"`)
@@ -116,69 +178,114 @@ func (p *Pkg) indexFile(f *ast.File) {
 			imports[filepath.Base(p)] = p
 		}
 	}
+	return imports
+}
+
+// importsAt returns the imports of the file in p containing pos
+func (p *Pkg) importsAt(pos token.Pos) map[string]string {
+	for _, f := range p.p.Files {
+		if f.FileStart <= pos && pos <= f.FileEnd {
+			return fileImports(f)
+		}
+	}
+	return map[string]string{}
+}
+
+func (p *Pkg) indexFile(f *ast.File) {
+	imports := fileImports(f)
 
 	ast.Inspect(f, func(n ast.Node) bool {
 		switch x := n.(type) {
 		case *ast.FuncDecl:
-			p.c.addFunc(*p, x, imports)
+			if fn := p.c.addFunc(*p, x, imports); fn.Exported() {
+				p.diagnoseDeprecations(fn)
+			}
 			// children can't be exported, let's not inspect them
 			return false
 		case *ast.GenDecl:
+			if x.Lparen == 0 && len(x.Specs) == 1 {
+				// The parser attaches the doc comment of an unparenthesized declaration such as
+				// "type Foo struct{}" to the GenDecl. Move it to the spec, where the code below
+				// and alias resolution expect to find it.
+				switch s := x.Specs[0].(type) {
+				case *ast.TypeSpec:
+					if s.Doc == nil {
+						s.Doc = x.Doc
+					}
+				case *ast.ValueSpec:
+					if s.Doc == nil {
+						s.Doc = x.Doc
+					}
+				}
+			}
 			if x.Tok == token.CONST || x.Tok == token.VAR {
 				// const or var declaration
-				for _, s := range x.Specs {
-					p.c.addGenDecl(*p, x.Tok, s.(*ast.ValueSpec), imports)
+				// prev is the last const spec having values. A const spec having no values repeats its type and values.
+				var prev *ast.ValueSpec
+				for iota, s := range x.Specs {
+					vs := s.(*ast.ValueSpec)
+					if x.Tok == token.CONST && len(vs.Values) > 0 {
+						prev = vs
+					} else if x.Tok == token.CONST && prev != nil {
+						vs = &ast.ValueSpec{Comment: vs.Comment, Doc: vs.Doc, Names: vs.Names, Type: prev.Type, Values: prev.Values}
+					}
+					for _, d := range p.c.addGenDecl(*p, x.Tok, vs, iota, imports) {
+						if d.Exported() {
+							p.diagnoseDeprecations(d)
+						}
+					}
 				}
 			}
 		case *ast.TypeSpec:
-			switch t := x.Type.(type) {
-			case *ast.ArrayType:
+			// tm is the TokenMaker added to the package's content, if any
+			var tm TokenMaker
+			// "type Handler (func(Event) error)" defines the same type as "type Handler func(Event) error"
+			switch t := unparen(x.Type).(type) {
+			case *ast.ArrayType, *ast.ChanType, *ast.FuncType, *ast.MapType, *ast.StarExpr:
 				// "type UUID [16]byte"
-				txt := p.getText(t.Pos(), t.End())
-				p.types[x.Name.Name] = typeDef{n: x, p: p}
-				p.c.addSimpleType(*p, x.Name.Name, p.Name(), txt, imports)
-			case *ast.FuncType:
+				// "type Events <-chan Event"
 				// "type PolicyFunc func(*Request) (*http.Response, error)"
+				// "type Headers map[string][]string"
+				// "type Ptr *Thing"
 				txt := p.getText(t.Pos(), t.End())
 				p.types[x.Name.Name] = typeDef{n: x, p: p}
-				p.c.addSimpleType(*p, x.Name.Name, p.Name(), txt, imports)
+				tm = p.c.addSimpleType(*p, x.Name.Name, p.Name(), txt, t, x.TypeParams, x.Doc, imports)
 			case *ast.Ident:
 				// "type ETag string"
 				p.types[x.Name.Name] = typeDef{n: x, p: p}
-				p.c.addSimpleType(*p, x.Name.Name, p.Name(), t.Name, imports)
+				tm = p.c.addSimpleType(*p, x.Name.Name, p.Name(), t.Name, t, x.TypeParams, x.Doc, imports)
 			case *ast.IndexExpr, *ast.IndexListExpr:
 				// "type Client GenericClient[BaseClient]"
 				// "type Client CompositeClient[BaseClient1, BaseClient2]"
 				txt := p.getText(t.Pos(), t.End())
 				p.types[x.Name.Name] = typeDef{n: x, p: p}
-				p.c.addSimpleType(*p, x.Name.Name, p.Name(), txt, imports)
+				tm = p.c.addSimpleType(*p, x.Name.Name, p.Name(), txt, t, x.TypeParams, x.Doc, imports)
 			case *ast.InterfaceType:
 				p.types[x.Name.Name] = typeDef{n: x, p: p}
-				in := p.c.addInterface(*p, x.Name.Name, p.Name(), t, imports)
+				in := p.c.addInterface(*p, x.Name.Name, p.Name(), x, imports)
+				tm = in
 				if in.Sealed {
 					p.diagnostics = append(p.diagnostics, CodeDiagnostic{
-						TargetID: in.ID(),
-						Level:    CodeDiagnosticLevelInfo,
-						Text:     sealedInterface,
+						DiagnosticID: sealedInterfaceID,
+						TargetID:     in.ID(),
+						Level:        CodeDiagnosticLevelInfo,
+						Text:         sealedInterface,
 					})
 				}
-			case *ast.MapType:
-				// "type opValues map[reflect.Type]interface{}"
-				txt := p.getText(t.Pos(), t.End())
-				p.c.addSimpleType(*p, x.Name.Name, p.Name(), txt, imports)
 			case *ast.SelectorExpr:
 				if ident, ok := t.X.(*ast.Ident); ok {
 					if impPath, ok := imports[ident.Name]; ok {
 						// alias in the same module could use type navigator directly
 						if _, _, found := strings.Cut(impPath, p.modulePath); found && !strings.Contains(impPath, "internal") {
 							expr := p.getText(t.Pos(), t.End())
-							p.c.addSimpleType(*p, x.Name.Name, p.Name(), expr, imports)
+							p.c.addSimpleType(*p, x.Name.Name, p.Name(), expr, t, x.TypeParams, x.Doc, imports)
 						}
 
 						// This is a re-exported type e.g. "type TokenCredential = shared.TokenCredential".
 						// Track it as an alias so we can later hoist its definition into this package.
 						ta := TypeAlias{
 							Name:          x.Name.Name,
+							doc:           x.Doc,
 							Package:       p,
 							QualifiedName: impPath + "." + t.Sel.Name,
 						}
@@ -187,31 +294,91 @@ func (p *Pkg) indexFile(f *ast.File) {
 						// Non-SDK underlying type e.g. "type EDMDateTime time.Time". Handle it like a simple type
 						// because we don't want to hoist its definition into this package.
 						expr := p.getText(t.Pos(), t.End())
-						p.c.addSimpleType(*p, x.Name.Name, p.Name(), expr, imports)
+						tm = p.c.addSimpleType(*p, x.Name.Name, p.Name(), expr, t, x.TypeParams, x.Doc, imports)
 					}
 				}
 			case *ast.StructType:
 				p.types[x.Name.Name] = typeDef{n: x, p: p}
 				s := p.c.addStruct(*p, x.Name.Name, p.Name(), x, imports)
+				tm = s
 				for _, t := range s.AnonymousFields {
 					// if t contains "." it must be exported
 					if !strings.Contains(t, ".") && unicode.IsLower(rune(t[0])) {
 						p.diagnostics = append(p.diagnostics, CodeDiagnostic{
-							Level:    CodeDiagnosticLevelError,
-							TargetID: s.ID(),
-							Text:     embedsUnexportedStruct + t,
+							DiagnosticID: embedsUnexportedStructID,
+							Level:        CodeDiagnosticLevelError,
+							TargetID:     s.ID(),
+							Text:         embedsUnexportedStruct + t,
 						})
 					}
 				}
 			default:
-				txt := p.getText(x.Pos(), x.End())
-				fmt.Printf("unhandled node type %T: %s\n", t, txt)
+				// the type has no line in the review, so target the package
+				if x.Name.IsExported() {
+					p.diagnostics = append(p.diagnostics, CodeDiagnostic{
+						DiagnosticID: unhandledTypeDefinitionID,
+						Level:        CodeDiagnosticLevelWarning,
+						TargetID:     p.Name(),
+						Text:         fmt.Sprintf("%s%s (%T)", unhandledTypeDefinition, x.Name.Name, t),
+					})
+				}
+			}
+			if tm != nil && tm.Exported() {
+				p.diagnoseDeprecations(tm)
 			}
 		}
 		return true
 	})
 }
 
+// unparen returns x without enclosing parentheses
+func unparen(x ast.Expr) ast.Expr {
+	for {
+		p, ok := x.(*ast.ParenExpr)
+		if !ok {
+			return x
+		}
+		x = p.X
+	}
+}
+
+// diagnoseDeprecations adds an Info diagnostic for t, and for each of t's exported fields and
+// methods, having a "Deprecated:" paragraph in its doc comment
+func (p *Pkg) diagnoseDeprecations(t TokenMaker) {
+	add := func(targetID string, doc *ast.CommentGroup) {
+		if notice := deprecationNotice(doc); notice != "" {
+			p.diagnostics = append(p.diagnostics, CodeDiagnostic{
+				DiagnosticID: deprecatedID,
+				Level:        CodeDiagnosticLevelInfo,
+				TargetID:     targetID,
+				Text:         notice,
+			})
+		}
+	}
+	switch x := t.(type) {
+	case Declaration:
+		add(x.ID(), x.doc)
+	case Func:
+		add(x.ID(), x.doc)
+	case Interface:
+		add(x.ID(), x.doc)
+		for name, m := range x.methods {
+			if m.Exported() {
+				add(x.ID()+"-"+name, m.doc)
+			}
+		}
+	case SimpleType:
+		add(x.ID(), x.doc)
+	case Struct:
+		add(x.ID(), x.doc)
+		for name, doc := range x.fieldDocs {
+			if unicode.IsUpper(rune(name[0])) {
+				add(x.ID()+"-"+name, doc)
+			}
+		}
+	}
+}
+
 // returns the text between [start, end]
 func (pkg Pkg) getText(start token.Pos, end token.Pos) string {
 	// convert to absolute position within the containing file
@@ -228,24 +395,35 @@ func (pkg Pkg) getText(start token.Pos, end token.Pos) string {
 }
 
 // iterates over the specified field list, for each field the specified
-// callback is invoked with the name of the field and the type name.  the field
-// name can be nil, e.g. anonymous fields in structs, unnamed return types etc.
-func (pkg Pkg) translateFieldList(fl []*ast.Field, cb func(*string, string)) {
+// callback is invoked with the name of the field, the type name and the type
+// expression.  the field name can be nil, e.g. anonymous fields in structs,
+// unnamed return types etc.
+func (pkg Pkg) translateFieldList(fl []*ast.Field, cb func(*string, string, ast.Expr)) {
 	for _, f := range fl {
 		t := pkg.getText(f.Type.Pos(), f.Type.End())
 		if len(f.Names) == 0 {
 			// field is an unnamed func return or anonymously embedded
-			cb(nil, t)
+			cb(nil, t, f.Type)
 		}
 		// field could have multiple names: in "type A struct { m, n int }",
 		// syntactically speaking, A has one field having two names
 		for _, name := range f.Names {
 			n := pkg.getText(name.Pos(), name.End())
-			cb(&n, t)
+			cb(&n, t, f.Type)
 		}
 	}
 }
 
+// translateExpr returns the translated text of the type expression x, whose text is oriVal. When the
+// package has been type checked, the text comes from x's type, which identifies the package declaring
+// each named type. Otherwise, and when x's type is unknown, translateExpr falls back to translateType.
+func (pkg Pkg) translateExpr(x ast.Expr, oriVal string, imports map[string]string) string {
+	if t, ok := pkg.typeOf(x); ok {
+		return t
+	}
+	return pkg.translateType(oriVal, imports)
+}
+
 // translateType change type string and add navigator mark:
 // 1. type in the same package or module, add navigator prefix <navigator> to the type string
 // 2. type in different module or system type, do nothing
@@ -254,7 +432,7 @@ func (pkg Pkg) translateType(oriVal string, imports map[string]string) string {
 	result := ""
 	for _, ch := range oriVal {
 		switch string(ch) {
-		case "*", "[", "]", " ", "(", ")", "{", "}", ",":
+		case "*", "[", "]", " ", "(", ")", "{", "}", ",", "|", "~":
 			if now != "" {
 				result += pkg.addTypeNavigator(now, imports)
 				now = ""
@@ -310,6 +488,8 @@ type TypeAlias struct {
 	// SourceMod is the module defining the type
 	SourceMod module.Version
 
+	// doc is the alias's doc comment
+	doc *ast.CommentGroup
 	// resolved indicates whether the alias has been resolved
 	resolved bool
 }
@@ -339,61 +519,99 @@ func (a *TypeAlias) Resolve(def typeDef) error {
 	delete(a.Package.c.SimpleTypes, a.Name)
 	var t TokenMaker
 	if def.n == nil || def.p == nil {
-		t = a.Package.c.addSimpleType(*a.Package, a.Name, a.Package.Name(), a.QualifiedName, nil)
+		t = a.Package.c.addSimpleType(*a.Package, a.Name, a.Package.Name(), a.QualifiedName, nil, nil, a.doc, nil)
 	} else {
-		switch n := def.n.Type.(type) {
+		switch n := unparen(def.n.Type).(type) {
 		case *ast.InterfaceType:
-			t = a.Package.c.addInterface(*def.p, a.Name, a.Package.Name(), n, nil)
+			t = a.Package.c.addInterface(*def.p, a.Name, a.Package.Name(), def.n, nil)
+			a.diagnoseMissingAliases(t.ID(), def, n.Methods)
 		case *ast.StructType:
 			t = a.Package.c.addStruct(*def.p, a.Name, a.Package.Name(), def.n, nil)
-			hoistMethodsForType(def.p, a.Name, a.Package)
-			// ensure that all struct field types that are structs are also aliased from this package
-			for _, field := range n.Fields.List {
-				fieldTypeName := unwrapStructFieldTypeName(field)
-				if fieldTypeName == "" {
-					// we can ignore this field
-					continue
-				}
-
-				// ensure that our package exports this type
-				found := false
-				for _, ta := range a.Package.TypeAliases {
-					if ta.Name == fieldTypeName {
-						found = true
-						break
-					}
-				}
-				if found {
-					continue
-				}
-
-				// no alias, add a diagnostic
-				a.Package.diagnostics = append(a.Package.diagnostics, CodeDiagnostic{
-					Level:    CodeDiagnosticLevelError,
-					TargetID: t.ID(),
-					Text:     missingAliasFor + fieldTypeName,
-				})
-			}
+			a.hoistMethods(def)
+			a.diagnoseMissingAliases(t.ID(), def, n.Fields)
 		case *ast.Ident:
-			t = a.Package.c.addSimpleType(*a.Package, a.Name, a.Package.Name(), def.n.Type.(*ast.Ident).Name, nil)
-			hoistMethodsForType(def.p, a.Name, a.Package)
+			t = a.Package.c.addSimpleType(*a.Package, a.Name, a.Package.Name(), n.Name, nil, def.n.TypeParams, def.n.Doc, nil)
+			a.hoistMethods(def)
+		case *ast.ArrayType, *ast.ChanType, *ast.FuncType, *ast.IndexExpr, *ast.IndexListExpr, *ast.MapType, *ast.StarExpr:
+			txt := def.p.getText(n.Pos(), n.End())
+			t = a.Package.c.addSimpleType(*def.p, a.Name, a.Package.Name(), txt, n, def.n.TypeParams, def.n.Doc, nil)
+			a.hoistMethods(def)
 		default:
-			fmt.Printf("unexpected node type %T\n", def.n.Type)
-			t = a.Package.c.addSimpleType(*a.Package, a.Name, a.Package.Name(), originalName, nil)
+			t = a.Package.c.addSimpleType(*a.Package, a.Name, a.Package.Name(), originalName, nil, nil, a.doc, nil)
+			a.Package.diagnostics = append(a.Package.diagnostics, CodeDiagnostic{
+				DiagnosticID: unhandledTypeDefinitionID,
+				Level:        CodeDiagnosticLevelWarning,
+				TargetID:     t.ID(),
+				Text:         fmt.Sprintf("%s%s (%T)", unhandledTypeDefinition, originalName, n),
+			})
 		}
 	}
 
 	if t != nil {
 		a.Package.diagnostics = append(a.Package.diagnostics, CodeDiagnostic{
-			Level:    level,
-			TargetID: t.ID(),
-			Text:     aliasFor + originalName,
+			DiagnosticID: aliasID,
+			Level:        level,
+			TargetID:     t.ID(),
+			Text:         aliasFor + originalName,
 		})
+		if t.Exported() {
+			a.Package.diagnoseDeprecations(t)
+		}
 	}
 	a.resolved = true
 	return nil
 }
 
+// hoistMethods adds the methods of def, the definition of the aliased type, to the package exporting the
+// alias, adding an Error diagnostic for each type in their signatures that applications can't name
+func (a *TypeAlias) hoistMethods(def typeDef) {
+	hoistMethodsForType(def.p, a.Name, a.Package)
+	for _, f := range def.p.p.Files {
+		imports := fileImports(f)
+		for _, decl := range f.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Recv == nil || receiverBase(fd.Recv.List[0].Type) != a.Name {
+				continue
+			}
+			if fn := NewFunc(*def.p, fd, imports).ForAlias(a.Package.Name()); fn.Exported() {
+				a.diagnoseMissingAlias(fn.ID(), def.p, imports, fd.Type, map[string]bool{})
+			}
+		}
+	}
+}
+
+// diagnoseMissingAliases adds an Error diagnostic for each type referred to by the exported members of def,
+// the definition of the aliased type, that applications can't name. That's a type declared in the package
+// defining the alias or in an internal package, which the package exporting the alias doesn't also export.
+func (a *TypeAlias) diagnoseMissingAliases(targetID string, def typeDef, members *ast.FieldList) {
+	imports := def.p.importsAt(def.n.Pos())
+	seen := map[string]bool{}
+	exportedMembers(members, func(_ string, x ast.Expr) {
+		a.diagnoseMissingAlias(targetID, def.p, imports, x, seen)
+	})
+}
+
+// diagnoseMissingAlias adds an Error diagnostic for each type in x, an expression in p, that applications can't
+// name, except those in seen, and adds them to seen
+func (a *TypeAlias) diagnoseMissingAlias(targetID string, p *Pkg, imports map[string]string, x ast.Expr, seen map[string]bool) {
+	for _, ref := range p.typeRefs(x, imports) {
+		importPath, name := splitQualifiedName(ref)
+		if seen[ref] || (importPath != p.importPath && !isInternalPath(importPath)) {
+			continue
+		}
+		seen[ref] = true
+		if slices.ContainsFunc(a.Package.TypeAliases, func(ta *TypeAlias) bool { return ta.QualifiedName == ref }) {
+			continue
+		}
+		a.Package.diagnostics = append(a.Package.diagnostics, CodeDiagnostic{
+			DiagnosticID: missingAliasID,
+			Level:        CodeDiagnosticLevelError,
+			TargetID:     targetID,
+			Text:         missingAliasFor + name,
+		})
+	}
+}
+
 // TODO: could be replaced by TokenMaker
 type typeDef struct {
 	// n is the AST node defining the type
@@ -46,7 +46,7 @@ func TestName(t *testing.T) {
 		t.Run("", func(t *testing.T) {
 			d, err := filepath.Abs(test.moduleRoot)
 			require.NoError(t, err)
-			p, err := NewPkg(filepath.Join(d, test.pkgPath), test.modulePath, d)
+			p, err := NewPkg(filepath.Join(d, test.pkgPath), test.modulePath, d, buildContext("", ""))
 			require.NoError(t, err)
 			require.Equal(t, test.want, p.Name())
 		})
@@ -0,0 +1,193 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"fmt"
+	"go/build"
+	"go/token"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// buildContext returns a context matching files built for goos and goarch with the build tags given
+// by --tags. Empty goos or goarch mean the default platform i.e., $GOOS and $GOARCH or the host's.
+func buildContext(goos, goarch string) *build.Context {
+	ctx := build.Default
+	if goos != "" {
+		ctx.GOOS = goos
+	}
+	if goarch != "" {
+		ctx.GOARCH = goarch
+	}
+	if ctx.GOOS != build.Default.GOOS || ctx.GOARCH != build.Default.GOARCH {
+		// as when cross compiling with the go command, cgo is disabled by default
+		ctx.CgoEnabled = false
+	}
+	ctx.BuildTags = append(slices.Clone(ctx.BuildTags), buildTags...)
+	return &ctx
+}
+
+// newPlatformsModule indexes the union of a module's declarations on the given platforms e.g. "linux/amd64".
+// The returned Module has the declaration of the first platform declaring each name, and an Info diagnostic
+// on each exported declaration that some platforms don't declare or that platforms declare differently.
+func newPlatformsModule(dir string, platforms []string) (*Module, error) {
+	var merged *Module
+	// texts maps the ID of each exported declaration to its text on each platform declaring it
+	texts := map[string]map[string]string{}
+	// owners maps the ID of each exported declaration to the import path of the package declaring it
+	owners := map[string]string{}
+	for _, platform := range platforms {
+		opSys, arch, found := strings.Cut(platform, "/")
+		if !found || opSys == "" || arch == "" {
+			return nil, fmt.Errorf(`invalid platform %q: must be like "linux/amd64"`, platform)
+		}
+		m, err := newModule(dir, buildContext(opSys, arch))
+		if err != nil {
+			return nil, err
+		}
+		for impPath, p := range m.Packages {
+			for _, t := range p.c.tokenMakers() {
+				if !t.Exported() {
+					continue
+				}
+				if texts[t.ID()] == nil {
+					texts[t.ID()] = map[string]string{}
+				}
+				texts[t.ID()][platform] = platformText(t)
+				owners[t.ID()] = impPath
+			}
+			// Review resolves aliases of types in other modules later, so they aren't content yet
+			for _, alias := range m.ExternalAliases {
+				if alias.Package != p || !token.IsExported(alias.Name) {
+					continue
+				}
+				id := p.Name() + "." + alias.Name
+				if texts[id] == nil {
+					texts[id] = map[string]string{}
+				}
+				texts[id][platform] = "type " + alias.Name + " = " + alias.QualifiedName
+				owners[id] = impPath
+			}
+		}
+		if merged == nil {
+			merged = m
+		} else {
+			merged.union(m)
+		}
+	}
+
+	ids := make([]string, 0, len(texts))
+	for id := range texts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		declaring, variants := []string{}, []string{}
+		// group platforms declaring the same text, in the order given by platforms
+		groups := map[string][]string{}
+		for _, platform := range platforms {
+			text, ok := texts[id][platform]
+			if !ok {
+				continue
+			}
+			declaring = append(declaring, platform)
+			if _, ok := groups[text]; !ok {
+				variants = append(variants, text)
+			}
+			groups[text] = append(groups[text], platform)
+		}
+		msg, diagID := "", platformOnlyID
+		if len(declaring) < len(platforms) {
+			msg = declaredOnlyFor + strings.Join(declaring, ", ")
+		} else if len(variants) > 1 {
+			diagID = platformDifferenceID
+			described := make([]string, len(variants))
+			for i, text := range variants {
+				described[i] = strings.Join(groups[text], ", ") + ": " + text
+			}
+			msg = declaredDifferentlyFor + strings.Join(described, "; ")
+		} else {
+			continue
+		}
+		p := merged.Packages[owners[id]]
+		p.diagnostics = append(p.diagnostics, CodeDiagnostic{
+			DiagnosticID: diagID,
+			Level:        CodeDiagnosticLevelInfo,
+			TargetID:     id,
+			Text:         msg,
+		})
+	}
+	return merged, nil
+}
+
+// union adds to m the packages, declarations and type aliases of other that m doesn't have
+func (m *Module) union(other *Module) {
+	for impPath, op := range other.Packages {
+		p, ok := m.Packages[impPath]
+		if !ok {
+			m.Packages[impPath] = op
+			for _, alias := range other.ExternalAliases {
+				if alias.Package == op {
+					m.ExternalAliases = append(m.ExternalAliases, alias)
+				}
+			}
+			continue
+		}
+		addMissing(p.c.Consts, op.c.Consts)
+		addMissing(p.c.Funcs, op.c.Funcs)
+		addMissing(p.c.Interfaces, op.c.Interfaces)
+		addMissing(p.c.SimpleTypes, op.c.SimpleTypes)
+		addMissing(p.c.Structs, op.c.Structs)
+		addMissing(p.c.Vars, op.c.Vars)
+		addMissing(p.types, op.types)
+		for _, alias := range op.TypeAliases {
+			if slices.ContainsFunc(p.TypeAliases, func(a *TypeAlias) bool { return a.Name == alias.Name }) {
+				continue
+			}
+			// only other's platform declares this alias
+			alias.Package = p
+			p.TypeAliases = append(p.TypeAliases, alias)
+			if slices.Contains(other.ExternalAliases, alias) {
+				m.ExternalAliases = append(m.ExternalAliases, alias)
+			}
+		}
+		for _, d := range op.diagnostics {
+			if !slices.Contains(p.diagnostics, d) {
+				p.diagnostics = append(p.diagnostics, d)
+			}
+		}
+	}
+}
+
+// addMissing adds to dst the entries of src whose keys aren't in dst
+func addMissing[T any](dst, src map[string]T) {
+	for k, v := range src {
+		if _, ok := dst[k]; !ok {
+			dst[k] = v
+		}
+	}
+}
+
+// platformText returns the text of t's review lines, for comparing t's declarations on different platforms
+func platformText(t TokenMaker) string {
+	var ln ReviewLine
+	switch x := t.(type) {
+	case Interface:
+		ln = x.MakeReviewLine()
+	case Struct:
+		ln = x.MakeReviewLine()
+	default:
+		ln = ReviewLine{Tokens: t.MakeTokens()}
+	}
+	texts := []string{}
+	forAll([]ReviewLine{ln}, func(l ReviewLine) {
+		if text := lineText(l, diffable); text != "" {
+			texts = append(texts, text)
+		}
+	})
+	return strings.Join(texts, "; ")
+}
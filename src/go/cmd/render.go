@@ -0,0 +1,198 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// formatExtensions maps the formats of reviews to the extensions of their files
+var formatExtensions = map[string]string{
+	"html":     ".html",
+	"json":     ".json",
+	"markdown": ".md",
+	"text":     ".txt",
+}
+
+// renderText writes the review's lines to w as Go-like plain text. Each line's children are indented
+// beneath it, except the declarations of a package, and diagnostics are comments at the end of their
+// target lines. Hidden lines are omitted.
+func renderText(w io.Writer, review CodeFile) error {
+	r := newTextRenderer(review)
+	r.render(review.ReviewLines, 0)
+	r.renderUntargeted()
+	_, err := io.WriteString(w, r.sb.String())
+	return err
+}
+
+// renderMarkdown writes the review to w as GitHub-flavored Markdown having a section for each package,
+// whose lines are a Go code block rendered as by renderText
+func renderMarkdown(w io.Writer, review CodeFile) error {
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "# %s\n", review.Name)
+	if review.PackageVersion != "" {
+		fmt.Fprintf(&sb, "\nVersion %s\n", review.PackageVersion)
+	}
+	r := newTextRenderer(review)
+	for _, pkg := range packageLines(review.ReviewLines) {
+		r.reset()
+		r.render(pkg, 0)
+		name := ""
+		for _, ln := range pkg {
+			if len(ln.Children) > 0 {
+				name = ln.LineID
+				break
+			}
+		}
+		fence := codeFence(r.sb.String())
+		fmt.Fprintf(&sb, "\n## `%s`\n\n%sgo\n%s%s\n", name, fence, r.sb.String(), fence)
+	}
+	r.reset()
+	r.renderUntargeted()
+	if r.sb.Len() > 0 {
+		fence := codeFence(r.sb.String())
+		fmt.Fprintf(&sb, "\n## Other diagnostics\n\n%sgo\n%s%s\n", fence, r.sb.String(), fence)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// packageLines splits lines, the top-level lines of a review, into the lines of each package
+func packageLines(lines []ReviewLine) [][]ReviewLine {
+	pkgs := [][]ReviewLine{}
+	start := 0
+	for i, ln := range lines {
+		if isPackageSeparator(ln) || i == len(lines)-1 {
+			pkgs = append(pkgs, lines[start:i+1])
+			start = i + 1
+		}
+	}
+	return pkgs
+}
+
+// isPackageSeparator returns whether ln is the line Review adds between packages
+func isPackageSeparator(ln ReviewLine) bool {
+	return ln.IsContextEndLine && len(ln.Tokens) == 1 && ln.Tokens[0].Value == packageSeparator
+}
+
+// codeFence returns a fence for a Markdown code block containing s. That's three backticks unless
+// s contains a run of three or more, in which case the fence must be longer.
+func codeFence(s string) string {
+	longest, run := 0, 0
+	for _, ch := range s {
+		if ch == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// textRenderer renders review lines as text
+type textRenderer struct {
+	sb strings.Builder
+	// diagnostics maps target IDs to their diagnostics
+	diagnostics map[string][]CodeDiagnostic
+	// rendered are the IDs of the lines rendered so far
+	rendered map[string]bool
+	// untargeted are the diagnostics whose targets aren't in the review, in their original order
+	untargeted []CodeDiagnostic
+	// blank indicates a blank line should precede the next line
+	blank bool
+	// started indicates a line has been rendered. Renderings don't begin with blank lines.
+	started bool
+}
+
+func newTextRenderer(review CodeFile) *textRenderer {
+	r := &textRenderer{diagnostics: map[string][]CodeDiagnostic{}, rendered: map[string]bool{}}
+	ids := map[string]bool{}
+	forAll(review.ReviewLines, func(ln ReviewLine) {
+		if ln.LineID != "" && !ln.IsHidden {
+			ids[ln.LineID] = true
+		}
+	})
+	for _, d := range review.Diagnostics {
+		if ids[d.TargetID] {
+			r.diagnostics[d.TargetID] = append(r.diagnostics[d.TargetID], d)
+		} else {
+			r.untargeted = append(r.untargeted, d)
+		}
+	}
+	return r
+}
+
+// reset discards the text rendered so far, leaving r ready to begin another rendering
+func (r *textRenderer) reset() {
+	r.sb.Reset()
+	r.blank, r.started = false, false
+}
+
+// render renders lines and their children. level is the depth of lines in the review.
+func (r *textRenderer) render(lines []ReviewLine, level int) {
+	// a package's declarations aren't indented
+	indent := max(level-1, 0)
+	for _, ln := range lines {
+		if ln.IsHidden {
+			continue
+		}
+		txt := lineText(ln, nil)
+		if isPackageSeparator(ln) {
+			txt = ""
+		}
+		if ln.LineID != "" && !r.rendered[ln.LineID] {
+			r.rendered[ln.LineID] = true
+			if ds := r.diagnostics[ln.LineID]; len(ds) > 0 {
+				texts := make([]string, len(ds))
+				for i, d := range ds {
+					texts[i] = diagnosticText(d)
+				}
+				txt = strings.TrimSpace(txt + " // " + strings.Join(texts, "; "))
+			}
+		}
+		if txt == "" {
+			r.blank = r.started
+		} else {
+			r.line(indent, txt)
+		}
+		if level == 0 && len(ln.Children) > 0 {
+			// separate the package clause from the package's declarations
+			r.blank = true
+		}
+		r.render(ln.Children, level+1)
+	}
+}
+
+// renderUntargeted renders diagnostics whose targets aren't in the review
+func (r *textRenderer) renderUntargeted() {
+	r.blank = r.started
+	for _, d := range r.untargeted {
+		r.line(0, fmt.Sprintf("// %s [target %s]", diagnosticText(d), d.TargetID))
+	}
+}
+
+// line renders a nonempty line having the given indentation
+func (r *textRenderer) line(indent int, s string) {
+	if r.blank {
+		r.sb.WriteByte('\n')
+		r.blank = false
+	}
+	r.sb.WriteString(strings.Repeat("\t", indent))
+	r.sb.WriteString(s)
+	r.sb.WriteByte('\n')
+	r.started = true
+}
+
+// diagnosticText returns the text of d prefixed with its level and followed by its ID, if any, e.g.
+// "Warning: Client.Get should have a context.Context first parameter (client-method-context)"
+func diagnosticText(d CodeDiagnostic) string {
+	s := fmt.Sprintf("%s: %s", d.Level, d.Text)
+	if d.DiagnosticID != "" {
+		s += " (" + d.DiagnosticID + ")"
+	}
+	return s
+}
@@ -0,0 +1,125 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// renderTestFile is a review of two packages having hidden lines, diagnostics and a struct tag
+var renderTestFile = CodeFile{
+	Diagnostics: []CodeDiagnostic{
+		{Level: CodeDiagnosticLevelWarning, TargetID: "pkg-NewClient", Text: "NewClient should return (*Client, error)", DiagnosticID: "client-constructor"},
+		{Level: CodeDiagnosticLevelInfo, TargetID: "pkg-NewClient", Text: "Deprecated: use New instead."},
+		{Level: CodeDiagnosticLevelError, TargetID: "pkg.Removed", Text: "Breaking change"},
+	},
+	Name: "pkg",
+	Navigation: []NavigationItem{
+		{Text: "pkg", NavigationID: "pkg", ChildItems: []NavigationItem{{Text: "Client", NavigationID: "pkg.Client"}}},
+		{Text: "pkg/sub", NavigationID: "pkg/sub"},
+	},
+	PackageVersion: "v1.2.0",
+	ReviewLines: []ReviewLine{
+		{
+			LineID: "pkg",
+			Tokens: []ReviewToken{{Value: "package", HasSuffixSpace: true}, {Value: "pkg"}},
+			Children: []ReviewLine{
+				{Tokens: []ReviewToken{{Value: "// Client is a client.", IsDocumentation: true}}},
+				{
+					LineID: "pkg.Client",
+					Tokens: []ReviewToken{{Value: "type", HasSuffixSpace: true}, {Value: "Client"}, {Value: "struct", HasPrefixSpace: true}},
+					Children: []ReviewLine{
+						{LineID: "pkg.Client-Tag", Tokens: []ReviewToken{{Value: "Tag"}, {Value: "   ", SkipDiff: true}, {Value: "string"}, {Value: "`json:\"tag\"`", HasPrefixSpace: true}}},
+						{LineID: "pkg.Client-hidden", IsHidden: true, Tokens: []ReviewToken{{Value: "hidden"}}},
+						{Tokens: []ReviewToken{}},
+						{LineID: "pkg-NewClient", Tokens: []ReviewToken{{Value: "func", HasSuffixSpace: true}, {Value: "NewClient"}, {Value: "()"}, {Kind: TokenKindTypeName, NavigateToID: "pkg.Client", Value: "*Client", HasPrefixSpace: true}}},
+					},
+				},
+				{IsContextEndLine: true},
+			},
+		},
+		{IsContextEndLine: true, Tokens: []ReviewToken{{Value: packageSeparator, SkipDiff: true}}},
+		{LineID: "pkg/sub", Tokens: []ReviewToken{{Value: "package", HasSuffixSpace: true}, {Value: "pkg/sub"}}, Children: []ReviewLine{
+			{LineID: "pkg/sub-F", Tokens: []ReviewToken{{Value: "func", HasSuffixSpace: true}, {Value: "F"}, {Value: "()"}}},
+		}},
+		{IsContextEndLine: true},
+	},
+}
+
+func TestRenderText(t *testing.T) {
+	sb := strings.Builder{}
+	require.NoError(t, renderText(&sb, renderTestFile))
+	require.Equal(t, `package pkg
+
+// Client is a client.
+type Client struct
+	Tag string `+"`json:\"tag\"`"+`
+
+	func NewClient() *Client // Warning: NewClient should return (*Client, error) (client-constructor); Info: Deprecated: use New instead.
+
+package pkg/sub
+
+func F()
+
+// Error: Breaking change [target pkg.Removed]
+`, sb.String())
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	sb := strings.Builder{}
+	require.NoError(t, renderMarkdown(&sb, renderTestFile))
+	require.Equal(t, "# pkg\n\nVersion v1.2.0\n\n## `pkg`\n\n```go\npackage pkg\n\n// Client is a client.\ntype Client struct\n\tTag string `json:\"tag\"`\n\n"+
+		"\tfunc NewClient() *Client // Warning: NewClient should return (*Client, error) (client-constructor); Info: Deprecated: use New instead.\n```\n\n"+
+		"## `pkg/sub`\n\n```go\npackage pkg/sub\n\nfunc F()\n```\n\n"+
+		"## Other diagnostics\n\n```go\n// Error: Breaking change [target pkg.Removed]\n```\n", sb.String())
+
+	require.Equal(t, "````", codeFence("a ``` b"))
+
+	out := t.TempDir()
+	code, _, stderr := run(t, "generate", filepath.Join("testdata", "test_lint"), out, "--format", "markdown", "-q")
+	require.Zero(t, code, stderr)
+	b, err := os.ReadFile(filepath.Join(out, "test_lint.md"))
+	require.NoError(t, err)
+	require.Contains(t, string(b), "\tfunc NewGadgetClient() *GadgetClient // Warning: NewGadgetClient should return (*GadgetClient, error) (client-constructor)\n")
+}
+
+func TestRenderHTML(t *testing.T) {
+	sb := strings.Builder{}
+	require.NoError(t, renderHTML(&sb, renderTestFile))
+	page := sb.String()
+	for _, s := range []string{
+		`<title>pkg v1.2.0</title>`,
+		`<span class="badge error">1 Error</span> <span class="badge warning">1 Warning</span> <span class="badge info">1 Info</span>`,
+		`<summary><a href="#L-pkg">pkg</a></summary><ul>` + "\n" + `<li><a href="#L-pkg.Client">Client</a></li>`,
+		`<div class="line" id="L-pkg.Client-Tag">` + "\t" + `<span class="text">Tag</span> <span class="text">string</span> <span class="text">` + "`json:&#34;tag&#34;`" + `</span></div>`,
+		`<div class="line hidden" id="L-pkg.Client-hidden">`,
+		`<a href="#L-pkg.Client" class="type">*Client</a><span class="badge warning">Warning: NewClient should return (*Client, error) (client-constructor)</span><span class="badge info">Info: Deprecated: use New instead.</span>`,
+		`<span class="badge error">Error: Breaking change</span> <code>pkg.Removed</code>`,
+		"<hr>",
+	} {
+		require.Contains(t, page, s)
+	}
+	// the page is self-contained
+	require.NotContains(t, page, "<script")
+	require.NotContains(t, page, "http")
+
+	// every link navigates to a line
+	for _, m := range regexp.MustCompile(`href="#([^"]+)"`).FindAllStringSubmatch(page, -1) {
+		require.Contains(t, page, `id="`+m[1]+`"`)
+	}
+	require.Equal(t, "L-pkg-~000028c~000020~00002aClient~000029~000020Get", anchor("pkg-(c *Client) Get"))
+	// escapes have a fixed width, so distinct LineIDs have distinct anchors
+	require.NotEqual(t, anchor("a b"), anchor("a\u020b"))
+
+	out := t.TempDir()
+	code, _, stderr := run(t, "generate", filepath.Join("testdata", "test_lint"), out, "--format", "html", "-q")
+	require.Zero(t, code, stderr)
+	require.FileExists(t, filepath.Join(out, "test_lint.html"))
+}
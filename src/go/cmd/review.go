@@ -12,10 +12,14 @@ import (
 	"strings"
 
 	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
 )
 
 var errExternalModule = errors.New("reviewed module exports a type defined in a different repository")
 
+// packageSeparator is the text of the line separating packages in a review
+var packageSeparator = strings.Repeat("━", 160)
+
 // Review represents an apiview review of an Azure SDK for Go module
 type Review struct {
 	// modules maps module paths to Modules implicated in this API review. It
@@ -28,15 +32,37 @@ type Review struct {
 	path string
 	// reviewed is the module being reviewed
 	reviewed *Module
+	// version overrides the reviewed module's version when set
+	version string
+	// workspace locates modules defining types the reviewed module exports by alias. It's loaded on demand.
+	workspace *workspace
+	// index loads the Modules implicated in this review. Reviews may share it.
+	index *moduleIndex
+	// config tunes lint rules and diagnostics. It's nil when the reviewed module has no config file.
+	config *config
+	// baseline is the path of a previous version of the reviewed module. When set, the review has a
+	// fatal diagnostic for each breaking change the reviewed module makes without a new major version.
+	baseline string
 }
 
 // NewReview creates a Review for the module at path p
 func NewReview(p string) (*Review, error) {
-	m, err := NewModule(p)
+	return newReview(p, newModuleIndex())
+}
+
+// newReview creates a Review for the module at path p, loading modules with index
+func newReview(p string, index *moduleIndex) (*Review, error) {
+	m, err := index.reviewedModule(p)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := loadConfig(p)
 	if err != nil {
 		return nil, err
 	}
 	r := &Review{
+		config:  cfg,
+		index:   index,
 		modules: map[string]*Module{},
 		name:    getPackageNameFromModPath(m.ModFile.Module.Mod.Path),
 		path:    p,
@@ -45,6 +71,68 @@ func NewReview(p string) (*Review, error) {
 	return r, err
 }
 
+// moduleIndex caches the Modules reviews load, so that reviews sharing an index, such as those of
+// every module in a repository, load each module once
+type moduleIndex struct {
+	// local maps absolute directory paths to the modules in them
+	local map[string]*Module
+	// external maps versions of modules in other repositories to those modules
+	external map[module.Version]*Module
+	// required has the paths of modules that reviewed modules require. The index keeps a reviewed module
+	// only when another may load it as an alias source, so that it doesn't hold every reviewed module's
+	// ASTs. It's nil when the index reviews a single module.
+	required map[string]bool
+}
+
+func newModuleIndex() *moduleIndex {
+	return &moduleIndex{local: map[string]*Module{}, external: map[module.Version]*Module{}}
+}
+
+// localModule returns the module in dir
+func (ix *moduleIndex) localModule(dir string) (*Module, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := ix.local[abs]; ok {
+		return m, nil
+	}
+	m, err := NewModule(dir)
+	if err == nil {
+		ix.local[abs] = m
+	}
+	return m, err
+}
+
+// reviewedModule returns the module in dir, which is to be reviewed. Unlike localModule, it keeps the
+// module only when ix.required has its path.
+func (ix *moduleIndex) reviewedModule(dir string) (*Module, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := ix.local[abs]; ok {
+		return m, nil
+	}
+	m, err := NewModule(dir)
+	if err == nil && ix.required[m.ModFile.Module.Mod.Path] {
+		ix.local[abs] = m
+	}
+	return m, err
+}
+
+// externalModule returns mod, which is in another repository, as described by GetExternalModule
+func (ix *moduleIndex) externalModule(mod module.Version, sums goSum) (*Module, error) {
+	if m, ok := ix.external[mod]; ok {
+		return m, nil
+	}
+	m, err := GetExternalModule(mod, sums)
+	if err == nil {
+		ix.external[mod] = m
+	}
+	return m, err
+}
+
 // AddModule adds a module to the review. Call this to add a module that exports
 // a type the reviewed module exports by alias.
 func (r *Review) AddModule(m *Module) error {
@@ -59,6 +147,13 @@ func (r *Review) AddModule(m *Module) error {
 }
 
 func (r *Review) Review() (CodeFile, error) {
+	version := r.version
+	if version == "" {
+		version = r.reviewed.Version
+	}
+	if version != "" && !semver.IsValid(version) {
+		return CodeFile{}, fmt.Errorf("invalid version %q for module %s: must be a semantic version such as v1.2.3", version, r.reviewed.ModFile.Module.Mod.Path)
+	}
 	if err := r.resolveAliases(); err != nil {
 		return CodeFile{}, err
 	}
@@ -66,6 +161,8 @@ func (r *Review) Review() (CodeFile, error) {
 	lines := []ReviewLine{}
 	nav := []NavigationItem{}
 	diagnostics := []CodeDiagnostic{}
+	guidelines := []CodeDiagnostic{}
+	rules := r.config.apply(lintRules)
 	packageNames := []string{}
 	for name, p := range r.reviewed.Packages {
 		// we use a prefixed path separator so that we can handle the "internal" module.
@@ -99,14 +196,19 @@ func (r *Review) Review() (CodeFile, error) {
 				},
 			},
 		}
+		if guidelineDiagnostics {
+			guidelines = append(guidelines, p.c.lint(rules)...)
+		}
+		// parsing a clone leaves p intact for other reviews sharing its module as an alias source
+		c := p.c.clone()
 		// TODO: reordering these calls reorders APIView output and can omit content
-		line.Children = append(line.Children, p.c.parseInterface()...)
-		line.Children = append(line.Children, p.c.parseStructs()...)
-		line.Children = append(line.Children, p.c.parseSimpleType()...)
-		line.Children = append(line.Children, p.c.parseVar()...)
-		line.Children = append(line.Children, p.c.parseConst()...)
-		line.Children = append(line.Children, p.c.parseFunc()...)
-		navItems := p.c.generateNavChildItems()
+		line.Children = append(line.Children, c.parseInterface()...)
+		line.Children = append(line.Children, c.parseStructs()...)
+		line.Children = append(line.Children, c.parseSimpleType()...)
+		line.Children = append(line.Children, c.parseVar()...)
+		line.Children = append(line.Children, c.parseConst()...)
+		line.Children = append(line.Children, c.parseFunc()...)
+		navItems := c.generateNavChildItems()
 		nav = append(nav, NavigationItem{
 			Text:         n,
 			NavigationID: n,
@@ -116,26 +218,23 @@ func (r *Review) Review() (CodeFile, error) {
 			},
 		})
 		diagnostics = append(diagnostics, p.diagnostics...)
-		slices.SortFunc(diagnostics, func(a CodeDiagnostic, b CodeDiagnostic) int {
-			targetCmp := strings.Compare(a.TargetID, b.TargetID)
-			if targetCmp != 0 {
-				return targetCmp
+		if parserDiagnostics {
+			for _, w := range p.warnings.list {
+				diagnostics = append(diagnostics, w.diagnostic())
 			}
-			// if the target IDs are the same then fall back to the text.
-			// this accounts for cases where there are multiple diagnostics
-			// for the same target ID.
-			return strings.Compare(a.Text, b.Text)
-		})
+		}
+		sortDiagnostics(diagnostics)
 		for _, n := range nav {
 			recursiveSortNavigation(n)
 		}
+		lines = append(lines, makeDocLines(p.doc, line.LineID)...)
 		lines = append(lines, line)
 		var tks []ReviewToken
 		if i < len(packageNames)-1 {
 			tks = append(tks, ReviewToken{
 				Kind:     TokenKindText,
 				SkipDiff: true,
-				Value:    strings.Repeat("━", 160),
+				Value:    packageSeparator,
 			})
 		}
 		lines = append(lines, ReviewLine{IsContextEndLine: true, Tokens: tks})
@@ -158,16 +257,58 @@ func (r *Review) Review() (CodeFile, error) {
 		}
 	})
 
-	return CodeFile{
-		Diagnostics: diagnostics,
-		Language:    "Go",
-		Name:        r.reviewed.Name,
-		Navigation:  nav,
+	cf := CodeFile{
+		Language:   "Go",
+		Name:       r.reviewed.Name,
+		Navigation: nav,
 		// this must match the value in src/dotnet/APIView/APIViewWeb/Languages/GoLanguageService.cs
-		ParserVersion: "0.1",
-		ReviewLines:   lines,
-		PackageName:   r.name,
-	}, nil
+		ParserVersion:  "0.1",
+		ReviewLines:    lines,
+		PackageName:    r.name,
+		PackageVersion: version,
+	}
+	if r.baseline != "" {
+		breaking, err := baselineDiagnostics(cf, r.reviewed.ModFile.Module.Mod.Path, r.baseline)
+		if err != nil {
+			return CodeFile{}, err
+		}
+		diagnostics = append(diagnostics, breaking...)
+	}
+	cf.Diagnostics = r.config.configure(append(diagnostics, guidelines...), lines)
+	sortDiagnostics(cf.Diagnostics)
+	return cf, nil
+}
+
+// sourceModule returns the module defining the type ta refers to. As with the go command, go.work use
+// directives and replace directives in go.work and the reviewed module's go.mod determine where that
+// module's source is. When none applies, sourceModule looks for the module in the reviewed module's
+// repository and then downloads it.
+func (r *Review) sourceModule(ta TypeAlias) (*Module, error) {
+	if r.workspace == nil {
+		w, err := loadWorkspace(r.path, r.reviewed.ModFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load workspace: %w", err)
+		}
+		r.workspace = w
+	}
+	dir, mod := r.workspace.locate(ta.SourceMod)
+	if dir != "" {
+		logger.Debug("using local source for module", "module", ta.SourceMod.Path, "dir", dir)
+		return r.index.localModule(dir)
+	}
+	if mod == ta.SourceMod {
+		m, err := r.findLocalModule(ta)
+		if !errors.Is(err, errExternalModule) {
+			return m, err
+		}
+	} else {
+		logger.Debug("using replacement for module", "module", ta.SourceMod, "replacement", mod)
+	}
+	sums, err := readGoSum(r.path)
+	if err != nil {
+		return nil, err
+	}
+	return r.index.externalModule(mod, sums)
 }
 
 // findLocalModule tries to find the source module defining a type in the same repository as
@@ -175,7 +316,7 @@ func (r *Review) Review() (CodeFile, error) {
 func (r *Review) findLocalModule(ta TypeAlias) (*Module, error) {
 	// localModulePath could be inlined but is instead separate for easier testing
 	if dir := localModulePath(ta.SourceMod, r.path); dir != "" {
-		return NewModule(dir)
+		return r.index.localModule(dir)
 	}
 	return nil, errExternalModule
 }
@@ -218,11 +359,11 @@ func (r *Review) resolveAliases() error {
 			ok  bool
 		)
 		if m, ok = r.modules[ta.SourceMod.Path]; !ok {
-			m, err = r.findLocalModule(*ta)
-			if errors.Is(err, errExternalModule) {
-				m, err = GetExternalModule(ta.SourceMod)
-			}
+			m, err = r.sourceModule(*ta)
 			if err == nil {
+				// a replacement may declare another module path, but the reviewed module imports its
+				// packages by the replaced module's path
+				m = m.replacing(ta.SourceMod.Path)
 				err = r.AddModule(m)
 			}
 			if err != nil {
@@ -254,6 +395,20 @@ func (r *Review) resolveAliases() error {
 	return nil
 }
 
+// sortDiagnostics sorts diagnostics by target ID and then text
+func sortDiagnostics(diagnostics []CodeDiagnostic) {
+	slices.SortFunc(diagnostics, func(a CodeDiagnostic, b CodeDiagnostic) int {
+		targetCmp := strings.Compare(a.TargetID, b.TargetID)
+		if targetCmp != 0 {
+			return targetCmp
+		}
+		// if the target IDs are the same then fall back to the text.
+		// this accounts for cases where there are multiple diagnostics
+		// for the same target ID.
+		return strings.Compare(a.Text, b.Text)
+	})
+}
+
 // forAll recursively applies a function to all lines and their children
 func forAll(lines []ReviewLine, fn func(ReviewLine)) {
 	for _, ln := range lines {
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"golang.org/x/mod/modfile"
 	"golang.org/x/mod/module"
 )
 
@@ -96,3 +97,62 @@ func TestLocalModulePath(t *testing.T) {
 		require.Equal(t, expect, actual)
 	}
 }
+
+func TestReplaceAndWorkspace(t *testing.T) {
+	fork := module.Version{Path: "example.com/fork", Version: "v1.1.0"}
+	proxy, _ := fileProxy(t, fork, filepath.Join("testdata", "test_replace", "fork"))
+	t.Setenv("GOFLAGS", "")
+	t.Setenv("GONOPROXY", "")
+	t.Setenv("GOPRIVATE", "")
+	t.Setenv("GOPROXY", proxy)
+	t.Setenv("GOSUMDB", "off")
+	t.Setenv("GOWORK", "")
+
+	for _, test := range []struct {
+		dir    string
+		fields []string
+	}{
+		// a local path replacement, a module version replacement and a local path replacement
+		// declaring a different module path
+		{dir: "test_replace", fields: []string{"InProgress", "Forked", "Renamed", "RenamedSub"}},
+		// a go.work use directive, and a go.work replacement overriding the module's
+		{dir: "test_workspace", fields: []string{"InProgress", "FromWorkspace"}},
+	} {
+		t.Run(test.dir, func(t *testing.T) {
+			review, err := createReview(filepath.Join("testdata", test.dir, "exporter"))
+			require.NoError(t, err)
+			for _, field := range test.fields {
+				found := searchTokens(review.ReviewLines, func(rt ReviewToken) bool { return rt.Value == field })
+				require.True(t, found, "review doesn't contain field %s", field)
+			}
+		})
+	}
+
+	t.Run("GOWORK=off", func(t *testing.T) {
+		// the module's replace directive for example.com/other points to a nonexistent directory
+		t.Setenv("GOWORK", "off")
+		_, err := createReview(filepath.Join("testdata", "test_workspace", "exporter"))
+		require.ErrorContains(t, err, "missing")
+	})
+}
+
+func TestReplacing(t *testing.T) {
+	m := &Module{
+		ModFile: &modfile.File{Module: &modfile.Module{Mod: module.Version{Path: "example.com/a"}}},
+		Packages: map[string]*Pkg{
+			"example.com/a":    {importPath: "example.com/a"},
+			"example.com/a/x":  {importPath: "example.com/a/x"},
+			"example.com/ab/x": {importPath: "example.com/ab/x"},
+		},
+	}
+	view := m.replacing("example.com/upstream")
+	require.Equal(t, "example.com/upstream", view.ModFile.Module.Mod.Path)
+	require.Equal(t, "example.com/a", m.ModFile.Module.Mod.Path)
+	keys := []string{}
+	for k := range view.Packages {
+		keys = append(keys, k)
+	}
+	// only paths within the module are remapped, so example.com/ab/x doesn't become example.com/upstreamb/x
+	require.ElementsMatch(t, []string{"example.com/a", "example.com/a/x", "example.com/ab/x", "example.com/upstream", "example.com/upstream/x"}, keys)
+	require.Same(t, m, m.replacing("example.com/a"))
+}
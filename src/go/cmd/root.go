@@ -4,38 +4,174 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"runtime/debug"
 
 	"github.com/spf13/cobra"
 )
 
+// exit codes, distinguishing failures CI pipelines may want to handle differently
+const (
+	// exitError is the exit code for failures not described below e.g. failing to write the output file
+	exitError = 1
+	// exitUsage is the exit code for invalid arguments and flags
+	exitUsage = 2
+	// exitParse is the exit code for failures to parse or index a module
+	exitParse = 3
+	// exitFatal is the exit code for reviews having fatal diagnostics, such as breaking changes
+	// without a new major version
+	exitFatal = 4
+	// exitCrash is the exit code for panics, which are bugs in apiviewgo. It differs from the Go
+	// runtime's exit code for unrecovered panics, which is the same as exitUsage.
+	exitCrash = 5
+)
+
+// codeError is an error having a particular exit code
+type codeError struct {
+	code int
+	err  error
+}
+
+func (e *codeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codeError) Unwrap() error {
+	return e.err
+}
+
+// usageError returns err with exit code exitUsage
+func usageError(err error) error {
+	return &codeError{code: exitUsage, err: err}
+}
+
+// parseError returns err with exit code exitParse
+func parseError(err error) error {
+	return &codeError{code: exitParse, err: err}
+}
+
+// usageArgs returns a positional args validator returning usage errors for args v rejects
+func usageArgs(v cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := v(cmd, args); err != nil {
+			return usageError(err)
+		}
+		return nil
+	}
+}
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use: "apiviewgo <moduleDir> <outputDir>",
 	Long: `apiviewgo outputs a file representing the public API of an Azure SDK for Go
 module in APIView format. It writes this file to <outputDir>/<module name>.json,
-overwriting any file of the same name.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) != 2 {
-			err := cmd.Help()
-			if err != nil {
-				fmt.Println(err)
-			}
-			return
-		}
-		err := CreateAPIView(args[0], args[1])
+overwriting any file of the same name. This is equivalent to "apiviewgo generate".`,
+	// ArbitraryArgs prevents cobra interpreting <moduleDir> as an unknown subcommand
+	Args:          usageArgs(cobra.ArbitraryArgs),
+	PreRunE:       validateGenerateArgs,
+	RunE:          runGenerate,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		l, err := newLogger(cmd.ErrOrStderr())
 		if err != nil {
-			fmt.Println(err)
+			return usageError(err)
 		}
+		logger = l
+		return nil
 	},
 }
 
+// baselineDir is the path of a previous version of the reviewed module. When set, the review includes
+// a fatal diagnostic for each breaking change unless the reviewed module has a new major version.
+var baselineDir string
+
+// packageVersion overrides the reviewed module's version when set
+var packageVersion string
+
+// reviewName overrides the name of the review, by default derived from the module path, when set
+var reviewName string
+
+// typeCheck determines whether NewModule type checks packages before indexing them. Type information
+// identifies the package declaring each named type, so navigation links are more accurate.
+var typeCheck bool
+
+// goos and goarch are the platform whose files NewModule indexes. When empty, NewModule indexes files
+// for the platform given by $GOOS and $GOARCH or, when those aren't set, the host platform.
+var goos, goarch string
+
+// buildTags are additional build tags satisfied when indexing
+var buildTags []string
+
+// platforms are platforms such as "linux/amd64" whose union NewModule indexes when set
+var platforms []string
+
+// quiet suppresses log messages below warning level
+var quiet bool
+
+// skipDiffTags excludes struct field tags from APIView's diffs of reviews
+var skipDiffTags bool
+
+func init() {
+	// persistent flags are the configuration shared by all commands
+	rootCmd.PersistentFlags().StringVar(&goos, "goos", "", "index files for this operating system (default $GOOS or the host's)")
+	rootCmd.PersistentFlags().StringVar(&goarch, "goarch", "", "index files for this architecture (default $GOARCH or the host's)")
+	rootCmd.PersistentFlags().StringSliceVar(&buildTags, "tags", nil, "additional build tags to satisfy when indexing")
+	rootCmd.PersistentFlags().StringSliceVar(&platforms, "platforms", nil, `review the union of these platforms e.g. "linux/amd64,windows/amd64", annotating declarations that differ`)
+	rootCmd.PersistentFlags().BoolVar(&guidelineDiagnostics, "guideline-diagnostics", true, "add a warning diagnostic to the review for each violation of the Azure SDK for Go design guidelines")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", `format of log messages written to stderr: "text" or "json"`)
+	rootCmd.PersistentFlags().BoolVar(&parserDiagnostics, "parser-diagnostics", false, "add a warning diagnostic to the review for each declaration apiviewgo can't fully describe")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "log only warnings and errors")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "log debug messages, including each parser warning")
+	rootCmd.PersistentFlags().BoolVar(&typeCheck, "typecheck", false, "type check packages to resolve type references (slower)")
+	rootCmd.PersistentFlags().BoolVar(&useCache, "cache", false, "keep downloaded modules in the apiviewgo cache for later runs")
+	rootCmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", "", "directory of the apiviewgo cache (default apiviewgo in the user's cache directory)")
+	addGenerateFlags(rootCmd)
+	rootCmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return usageError(err)
+	})
+}
+
+// addReviewFlags adds flags describing the reviewed module to a command reviewing a single module
+func addReviewFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&reviewName, "name", "", `name of the review e.g. "sdk/azcore" (by default, derived from the module path)`)
+	cmd.Flags().StringVar(&packageVersion, "version", "", "version of the module e.g. v1.2.3 (by default, the value of the module's moduleVersion const)")
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	os.Exit(execute(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// execute runs the command given by args, returning the process's exit code
+func execute(args []string, stdout, stderr io.Writer) (code int) {
+	rootCmd.SetArgs(args)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
+	// commands replace the logger according to the logging flags
+	defer func(l *slog.Logger) { logger = l }(logger)
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(stderr, "Error: apiviewgo crashed: %v\n%s", r, debug.Stack())
+			code = exitCrash
+		}
+	}()
+	cmd, err := rootCmd.ExecuteC()
+	if err == nil {
+		return 0
+	}
+	fmt.Fprintln(stderr, "Error:", err)
+	var ce *codeError
+	if !errors.As(err, &ce) {
+		return exitError
+	}
+	if ce.code == exitUsage {
+		fmt.Fprintf(stderr, "Run '%s --help' for usage.\n", cmd.CommandPath())
 	}
+	return ce.code
 }
@@ -0,0 +1,156 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+)
+
+// run executes the command given by args, returning its exit code and output. It resets all flags
+// afterward because cobra doesn't reset them between executions.
+func run(t *testing.T, args ...string) (int, string, string) {
+	stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+	code := execute(args, &stdout, &stderr)
+	reset := func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			require.NoError(t, sv.Replace(nil))
+		} else {
+			require.NoError(t, f.Value.Set(f.DefValue))
+		}
+		f.Changed = false
+	}
+	for _, c := range append([]*cobra.Command{rootCmd}, rootCmd.Commands()...) {
+		c.Flags().VisitAll(reset)
+		c.PersistentFlags().VisitAll(reset)
+	}
+	return code, stdout.String(), stderr.String()
+}
+
+func TestExitCodes(t *testing.T) {
+	broken := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(broken, "go.mod"), []byte("not a go.mod file"), 0644))
+	for _, test := range []struct {
+		args []string
+		code int
+	}{
+		{args: []string{}, code: exitUsage},
+		{args: []string{"--unknown", "testdata/test_vars", "."}, code: exitUsage},
+		{args: []string{"testdata/test_vars"}, code: exitUsage},
+		{args: []string{"testdata/test_vars", ".", "--output", "-"}, code: exitUsage},
+		{args: []string{"generate", "testdata/nonexistent", "--output", "-"}, code: exitUsage},
+		{args: []string{"generate", "testdata/test_vars", "--output", "-", "--version", "1.0"}, code: exitUsage},
+		{args: []string{"generate", "testdata/test_vars", "--output", "-", "--format", "xml"}, code: exitUsage},
+		{args: []string{"lint", "testdata/test_vars", "--fail-on", "severe"}, code: exitUsage},
+		{args: []string{"diff", "testdata/test_diff/old"}, code: exitUsage},
+		{args: []string{"generate", broken, "--output", "-"}, code: exitParse},
+		{args: []string{"inspect", broken}, code: exitParse},
+		{args: []string{"generate", "testdata/test_diff/new", "--output", "-", "--baseline", "testdata/test_diff/old"}, code: exitFatal},
+		{args: []string{"diff", "testdata/test_diff/old", "testdata/test_diff/new"}, code: exitFatal},
+		{args: []string{"lint", "testdata/test_diagnostics"}, code: exitFatal},
+		{args: []string{"lint", "testdata/test_diagnostics", "--fail-on", "fatal"}, code: 0},
+		{args: []string{"diff", "testdata/test_diff/old", "testdata/test_diff/old"}, code: 0},
+	} {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			code, _, stderr := run(t, append(test.args, "--quiet")...)
+			require.Equal(t, test.code, code, stderr)
+			if code != 0 {
+				require.Contains(t, stderr, "Error: ")
+			}
+		})
+	}
+}
+
+func TestCrash(t *testing.T) {
+	crash := &cobra.Command{
+		Use: "crash",
+		Run: func(*cobra.Command, []string) {
+			panic("something went wrong")
+		},
+	}
+	rootCmd.AddCommand(crash)
+	defer rootCmd.RemoveCommand(crash)
+	code, _, stderr := run(t, "crash")
+	require.Equal(t, exitCrash, code)
+	require.Contains(t, stderr, "Error: apiviewgo crashed: something went wrong\n")
+	// the stack trace helps to report the bug
+	require.Contains(t, stderr, "goroutine")
+}
+
+func TestGenerate(t *testing.T) {
+	t.Run("stdout", func(t *testing.T) {
+		code, stdout, stderr := run(t, "generate", "testdata/test_vars", "-o", "-", "--compact", "--name", "custom/name", "--version", "v1.2.3", "-q")
+		require.Zero(t, code, stderr)
+		require.Empty(t, stderr)
+		require.NotContains(t, stdout, "\n")
+		var review CodeFile
+		require.NoError(t, json.Unmarshal([]byte(stdout), &review))
+		require.Equal(t, "custom/name", review.PackageName)
+		require.Equal(t, "v1.2.3", review.PackageVersion)
+	})
+	t.Run("outputDir", func(t *testing.T) {
+		dir := t.TempDir()
+		code, stdout, stderr := run(t, "testdata/test_vars", dir)
+		require.Zero(t, code, stderr)
+		require.Empty(t, stdout)
+		f := filepath.Join(dir, "test_vars.json")
+		require.Contains(t, stderr, "wrote review")
+		require.Contains(t, stderr, f)
+		b, err := os.ReadFile(f)
+		require.NoError(t, err)
+		var review CodeFile
+		require.NoError(t, json.Unmarshal(b, &review))
+		require.Equal(t, "test_vars", review.Name)
+	})
+}
+
+func TestLintAndInspect(t *testing.T) {
+	code, stdout, _ := run(t, "lint", "testdata/test_diagnostics", "--fail-on", "fatal", "-q")
+	require.Zero(t, code)
+	require.Contains(t, stdout, "Error: test_diagnostics.ExportedStruct: "+embedsUnexportedStruct+"unexportedStruct ("+embedsUnexportedStructID+")\n")
+	require.Contains(t, stdout, "Info: test_diagnostics.Sealed: "+sealedInterface+" ("+sealedInterfaceID+")\n")
+
+	code, stdout, _ = run(t, "inspect", "testdata/test_subpackage", "-q")
+	require.Zero(t, code)
+	require.Contains(t, stdout, "PACKAGE")
+	require.Contains(t, stdout, "test_subpackage/subpackage")
+}
+
+func TestLogging(t *testing.T) {
+	code, stdout, stderr := run(t, "lint", "testdata/test_warnings", "--parser-diagnostics", "--fail-on", "fatal", "--verbose", "--log-format", "json")
+	require.Zero(t, code, stderr)
+	levels := map[string]int{}
+	for _, ln := range strings.Split(strings.TrimSpace(stderr), "\n") {
+		var record struct {
+			Level string `json:"level"`
+			Msg   string `json:"msg"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(ln), &record), ln)
+		levels[record.Level]++
+	}
+	// a debug message for each warning, and a summary
+	require.Equal(t, map[string]int{"DEBUG": 2, "INFO": 1, "WARN": 1}, levels)
+	require.Contains(t, stdout, "Warning: test_warnings: "+parserWarning+"unhandled expression value type *ast.IndexExpr (warnings.go:7: names[0]) (parser-warning)\n")
+	require.Contains(t, stdout, "Warning: test_warnings.Handlers: "+parserWarning+"unhandled declaration type *ast.ArrayType (warnings.go:10: []func()) (parser-warning)\n")
+
+	// without --parser-diagnostics, warnings aren't diagnostics
+	code, stdout, stderr = run(t, "lint", "testdata/test_warnings", "--quiet")
+	require.Zero(t, code)
+	require.Equal(t, "No diagnostics\n", stdout)
+	require.Contains(t, stderr, "level=WARN")
+	require.NotContains(t, stderr, "level=INFO")
+
+	code, _, _ = run(t, "lint", "testdata/test_warnings", "--quiet", "--verbose")
+	require.Equal(t, exitUsage, code)
+	code, _, _ = run(t, "lint", "testdata/test_warnings", "--log-format", "xml")
+	require.Equal(t, exitUsage, code)
+}
@@ -0,0 +1,232 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// guidelinesURI is the URI of the Azure SDK for Go design guidelines
+const guidelinesURI = "https://azure.github.io/azure-sdk/golang_introduction.html"
+
+// URIs of the guidelines lint rules check
+const (
+	clientConstructorGuidelineURI = guidelinesURI + "#golang-client-constructor"
+	contextGuidelineURI           = guidelinesURI + "#golang-api-context"
+	optionsNameGuidelineURI       = guidelinesURI + "#golang-options-naming"
+	optionsParameterGuidelineURI  = guidelinesURI + "#golang-options-parameter"
+)
+
+// guidelineDiagnostics adds a diagnostic to reviews for each violation of the Azure SDK for Go design guidelines
+var guidelineDiagnostics bool
+
+// lintRule checks a package's content for violations of the Azure SDK for Go design guidelines.
+// Review runs each rule over the content of every package it reviews, adding a diagnostic for
+// each finding.
+type lintRule struct {
+	// id identifies the rule. It's the DiagnosticID of the rule's diagnostics.
+	id string
+	// helpLink is the URI of the guideline the rule checks
+	helpLink string
+	// level is the level of the rule's diagnostics
+	level CodeDiagnosticLevel
+	// check returns the rule's findings in c
+	check func(c *content) []lintFinding
+}
+
+// lintFinding is a violation found by a lintRule
+type lintFinding struct {
+	// targetID is the LineID of the review line having the violation
+	targetID string
+	text     string
+}
+
+// lintRules are the rules Review runs. Add a rule by appending it here.
+var lintRules = []lintRule{
+	{
+		id:       "client-method-context",
+		helpLink: contextGuidelineURI,
+		level:    CodeDiagnosticLevelWarning,
+		check:    checkClientMethodContext,
+	},
+	{
+		id:       "client-method-options",
+		helpLink: optionsParameterGuidelineURI,
+		level:    CodeDiagnosticLevelWarning,
+		check:    checkClientMethodOptions,
+	},
+	{
+		id:       "client-constructor",
+		helpLink: clientConstructorGuidelineURI,
+		level:    CodeDiagnosticLevelWarning,
+		check:    checkClientConstructors,
+	},
+	{
+		id:       "options-name",
+		helpLink: optionsNameGuidelineURI,
+		level:    CodeDiagnosticLevelWarning,
+		check:    checkOptionsNames,
+	},
+}
+
+// lint returns a diagnostic for each finding of rules in c
+func (c *content) lint(rules []lintRule) []CodeDiagnostic {
+	diagnostics := []CodeDiagnostic{}
+	for _, r := range rules {
+		for _, f := range r.check(c) {
+			diagnostics = append(diagnostics, CodeDiagnostic{
+				DiagnosticID: r.id,
+				HelpLinkURI:  r.helpLink,
+				Level:        r.level,
+				TargetID:     f.targetID,
+				Text:         f.text,
+			})
+		}
+	}
+	return diagnostics
+}
+
+// navigatorRegex matches the navigation markers translateType adds to type names e.g. "<azcore.Policy>"
+var navigatorRegex = regexp.MustCompile(`<[\w./-]+>`)
+
+// plainType returns t without navigation markers e.g. "*ClientGetOptions" for "*<pkg.ClientGetOptions>ClientGetOptions"
+func plainType(t string) string {
+	return navigatorRegex.ReplaceAllString(t, "")
+}
+
+// isClient returns whether the type having the given name is a client by Azure SDK convention
+func isClient(name string) bool {
+	return strings.HasSuffix(name, "Client")
+}
+
+// clientMethods returns the exported methods of c's exported clients that send requests, sorted by ID.
+// It omits methods returning subclients e.g. "NewWidgetsClient", which needn't take a context or
+// options, and methods that can't fail e.g. "Endpoint() string", which send no requests.
+func clientMethods(c *content) []Func {
+	methods := []Func{}
+	for name, s := range c.Structs {
+		if !s.Exported() || !isClient(name) {
+			continue
+		}
+		for _, m := range c.findMethods(name) {
+			if m.Exported() && !(strings.HasPrefix(m.Name(), "New") && isClient(m.Name())) && sendsRequests(m) {
+				methods = append(methods, m)
+			}
+		}
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].ID() < methods[j].ID() })
+	return methods
+}
+
+// sendsRequests returns whether m, a client method, sends requests. That's the case for pagers and for
+// methods returning an error, which accessors such as "Endpoint() string" don't.
+func sendsRequests(m Func) bool {
+	n := len(m.Returns)
+	return isPager(m) || (n > 0 && plainType(m.Returns[n-1]) == "error")
+}
+
+// isPager returns whether m creates a pager, by convention a method named like "NewListPager"
+func isPager(m Func) bool {
+	return strings.HasPrefix(m.Name(), "New") && strings.HasSuffix(m.Name(), "Pager")
+}
+
+// checkClientMethodContext finds client methods whose first parameter isn't a context.Context. Pagers
+// are exempt because they send requests only when the caller pages, passing a context then.
+func checkClientMethodContext(c *content) []lintFinding {
+	findings := []lintFinding{}
+	for _, m := range clientMethods(c) {
+		if isPager(m) {
+			continue
+		}
+		if len(m.paramTypes) == 0 || plainType(m.paramTypes[0]) != "context.Context" {
+			findings = append(findings, lintFinding{
+				targetID: m.ID(),
+				text:     fmt.Sprintf("%s.%s should have a context.Context first parameter", m.receiverBase, m.Name()),
+			})
+		}
+	}
+	return findings
+}
+
+// checkClientMethodOptions finds client methods whose last parameter isn't a pointer to an options struct
+func checkClientMethodOptions(c *content) []lintFinding {
+	findings := []lintFinding{}
+	for _, m := range clientMethods(c) {
+		if n := len(m.paramTypes); n == 0 || !isOptionsType(m.paramTypes[n-1]) {
+			findings = append(findings, lintFinding{
+				targetID: m.ID(),
+				text:     fmt.Sprintf("%s.%s should have a *%s last parameter", m.receiverBase, m.Name(), optionsName(m)),
+			})
+		}
+	}
+	return findings
+}
+
+// checkClientConstructors finds client constructors e.g. "NewWidgetClient" that don't return (*WidgetClient, error)
+func checkClientConstructors(c *content) []lintFinding {
+	findings := []lintFinding{}
+	for _, f := range sortedFuncs(c) {
+		name := f.Name()
+		if f.ReceiverType != "" || !f.Exported() || !strings.HasPrefix(name, "New") || !isClient(name) {
+			continue
+		}
+		client := strings.TrimPrefix(name, "New")
+		if len(f.Returns) != 2 || plainType(f.Returns[0]) != "*"+client || plainType(f.Returns[1]) != "error" {
+			findings = append(findings, lintFinding{
+				targetID: f.ID(),
+				text:     fmt.Sprintf("%s should return (*%s, error)", name, client),
+			})
+		}
+	}
+	return findings
+}
+
+// checkOptionsNames finds client methods whose options parameter isn't named after the client and method
+func checkOptionsNames(c *content) []lintFinding {
+	findings := []lintFinding{}
+	for _, m := range clientMethods(c) {
+		n := len(m.paramTypes)
+		if n == 0 || !isOptionsType(m.paramTypes[n-1]) {
+			// checkClientMethodOptions reports this
+			continue
+		}
+		if actual, expected := strings.TrimPrefix(plainType(m.paramTypes[n-1]), "*"), optionsName(m); actual != expected {
+			findings = append(findings, lintFinding{
+				targetID: m.ID(),
+				text:     fmt.Sprintf("The options of %s.%s should be named %s, not %s", m.receiverBase, m.Name(), expected, actual),
+			})
+		}
+	}
+	return findings
+}
+
+// isOptionsType returns whether t is a pointer to an options struct e.g. "*ClientGetOptions"
+func isOptionsType(t string) bool {
+	t = plainType(t)
+	return strings.HasPrefix(t, "*") && strings.HasSuffix(t, "Options")
+}
+
+// optionsName returns the conventional name of m's options type, which combines the names of the
+// client and method e.g. "WidgetClientGetOptions" for WidgetClient.Get and "WidgetClientListOptions"
+// for WidgetClient.NewListPager
+func optionsName(m Func) string {
+	name := m.Name()
+	if isPager(m) {
+		name = strings.TrimSuffix(strings.TrimPrefix(name, "New"), "Pager")
+	}
+	return m.receiverBase + name + "Options"
+}
+
+// sortedFuncs returns the Funcs of c sorted by ID
+func sortedFuncs(c *content) []Func {
+	funcs := make([]Func, 0, len(c.Funcs))
+	for _, f := range c.Funcs {
+		funcs = append(funcs, f)
+	}
+	sort.Slice(funcs, func(i, j int) bool { return funcs[i].ID() < funcs[j].ID() })
+	return funcs
+}
@@ -0,0 +1,68 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintRules(t *testing.T) {
+	review, err := createReview(filepath.Join("testdata", "test_lint"))
+	require.NoError(t, err)
+	type finding struct{ id, target string }
+	expected := []finding{
+		{"client-method-options", "test_lint-(c *GadgetClient) Update"},
+		{"client-method-context", "test_lint-(c *GadgetClient) Delete"},
+		{"options-name", "test_lint-(c *GadgetClient) Create"},
+		{"client-constructor", "test_lint-NewGadgetClient"},
+	}
+	actual := []finding{}
+	for _, d := range review.Diagnostics {
+		require.Equal(t, CodeDiagnosticLevelWarning, d.Level)
+		actual = append(actual, finding{d.DiagnosticID, d.TargetID})
+	}
+	require.ElementsMatch(t, expected, actual)
+
+	// each rule links to the guideline it checks
+	links := map[string]string{}
+	for _, d := range review.Diagnostics {
+		links[d.DiagnosticID] = d.HelpLinkURI
+	}
+	require.Equal(t, map[string]string{
+		"client-constructor":    clientConstructorGuidelineURI,
+		"client-method-context": contextGuidelineURI,
+		"client-method-options": optionsParameterGuidelineURI,
+		"options-name":          optionsNameGuidelineURI,
+	}, links)
+
+	// each diagnostic targets a line of the review
+	lineIDs := map[string]bool{}
+	forAll(review.ReviewLines, func(ln ReviewLine) { lineIDs[ln.LineID] = true })
+	for _, d := range review.Diagnostics {
+		require.True(t, lineIDs[d.TargetID], d.TargetID)
+	}
+
+	// lint identifies the rule each diagnostic violates
+	code, stdout, stderr := run(t, "lint", filepath.Join("testdata", "test_lint"), "-q")
+	require.Zero(t, code, stderr)
+	require.Contains(t, stdout, "Warning: test_lint-NewGadgetClient: NewGadgetClient should return (*GadgetClient, error) (client-constructor)\n")
+
+	code, stdout, stderr = run(t, "lint", filepath.Join("testdata", "test_lint"), "-q", "--guideline-diagnostics=false")
+	require.Zero(t, code, stderr)
+	require.Equal(t, "No diagnostics\n", stdout)
+}
+
+func TestPlainType(t *testing.T) {
+	for in, out := range map[string]string{
+		"*<test_lint.GadgetClientDeleteOptions>GadgetClientDeleteOptions": "*GadgetClientDeleteOptions",
+		"context.Context":                    "context.Context",
+		"map[string]<-chan <pkg.Event>Event": "map[string]<-chan Event",
+		"<github.com/a/b-c.Thing>Thing":      "Thing",
+	} {
+		require.Equal(t, out, plainType(in))
+	}
+}
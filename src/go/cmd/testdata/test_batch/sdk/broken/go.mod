@@ -0,0 +1 @@
+not a go.mod file
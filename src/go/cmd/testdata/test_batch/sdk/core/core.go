@@ -0,0 +1,15 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package core
+
+// Options configures a client
+type Options struct {
+	Retries int
+}
+
+// Clone returns a copy of the options
+func (o *Options) Clone() *Options {
+	cp := *o
+	return &cp
+}
@@ -0,0 +1,3 @@
+module github.com/Azure/azure-sdk-tools/src/go/cmd/testdata/test_batch/sdk/core
+
+go 1.18
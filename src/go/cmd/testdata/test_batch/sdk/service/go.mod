@@ -0,0 +1,5 @@
+module github.com/Azure/azure-sdk-tools/src/go/cmd/testdata/test_batch/sdk/service
+
+go 1.18
+
+require github.com/Azure/azure-sdk-tools/src/go/cmd/testdata/test_batch/sdk/core v1.0.0
@@ -0,0 +1,11 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package service
+
+import "github.com/Azure/azure-sdk-tools/src/go/cmd/testdata/test_batch/sdk/core"
+
+type Options = core.Options
+
+// Client is a service client
+type Client struct{}
@@ -0,0 +1,3 @@
+module github.com/Azure/azure-sdk-tools/src/go/cmd/testdata/test_config
+
+go 1.18
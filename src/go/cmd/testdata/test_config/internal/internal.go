@@ -0,0 +1,3 @@
+package internal
+
+type Handle struct{}
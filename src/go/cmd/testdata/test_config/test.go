@@ -0,0 +1,45 @@
+package test_config
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-tools/src/go/cmd/testdata/test_config/internal"
+)
+
+type Client struct{}
+
+// NewClient doesn't return an error, which the config suppresses.
+func NewClient() *Client {
+	return &Client{}
+}
+
+// Delete doesn't take a context.
+func (c *Client) Delete(name string, options *ClientDeleteOptions) error {
+	return nil
+}
+
+// Update doesn't take options, which the config doesn't check.
+func (c *Client) Update(ctx context.Context, name string) error {
+	return nil
+}
+
+type ClientDeleteOptions struct{}
+
+// Put is deprecated, which the config reports as a warning.
+//
+// Deprecated: use Update instead.
+func (c *Client) Put(ctx context.Context, name string, options *ClientPutOptions) error {
+	return nil
+}
+
+type ClientPutOptions struct{}
+
+// Open exposes an internal type, which the config suppresses.
+func Open() *internal.Handle {
+	return nil
+}
+
+// Sealed can't be implemented, which the config doesn't report.
+type Sealed interface {
+	sealed()
+}
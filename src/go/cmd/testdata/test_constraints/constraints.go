@@ -0,0 +1,55 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package test_constraints
+
+import "github.com/Azure/azure-sdk-tools/src/go/cmd/testdata/test_constraints/sub"
+
+// Number is a constraint permitting numeric types.
+type Number interface {
+	~int | ~int64 | ~float64
+}
+
+// Key is a constraint embedding another.
+type Key interface {
+	comparable
+	Number
+}
+
+// Stringer isn't a constraint.
+type Stringer interface {
+	String() string
+}
+
+// ID re-exports a constraint.
+type ID = sub.ID
+
+type Ints[T ~int | ~int64] []T
+
+// Stats uses a constraint as a field type, which doesn't compile.
+type Stats struct {
+	Count Number
+	Name  string
+}
+
+func Sum[T Number](values ...T) T {
+	var sum T
+	for _, v := range values {
+		sum += v
+	}
+	return sum
+}
+
+// Lookup uses a constraint as a parameter type, which doesn't compile.
+func Lookup(id sub.ID) string {
+	return ""
+}
+
+// Celsius is a defined type other than an interface.
+type Celsius float64
+
+// Temperature's only element is a defined type other than an interface, which is a type term
+// only type checking can identify.
+type Temperature interface {
+	Celsius
+}
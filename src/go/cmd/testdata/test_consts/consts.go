@@ -0,0 +1,40 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package test_consts
+
+type Color int
+
+const (
+	Red Color = iota
+	Green
+	Blue
+)
+
+type Flag uint
+
+const (
+	FlagA Flag = 1 << iota
+	FlagB
+	FlagC
+)
+
+const (
+	_  = iota
+	KB = 1 << (10 * iota)
+	MB
+)
+
+const Greeting = "hello, " + "world"
+
+const Max, Min = 10, -10
+
+const Hex = 0x10
+
+var A, B = 1, 2
+
+var X, Y = pair()
+
+func pair() (int, int) {
+	return 0, 0
+}
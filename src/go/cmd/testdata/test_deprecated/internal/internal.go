@@ -0,0 +1,6 @@
+package internal
+
+type Hoisted struct{}
+
+// Deprecated: use something else.
+func (Hoisted) Method() {}
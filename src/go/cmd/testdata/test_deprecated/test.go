@@ -0,0 +1,46 @@
+package test_deprecated
+
+import "github.com/Azure/azure-sdk-tools/src/go/cmd/testdata/test_deprecated/internal"
+
+// Client is a client.
+//
+// Deprecated: use NewClient instead.
+type Client struct {
+	// Endpoint is the client's endpoint.
+	//
+	// Deprecated: set URL instead.
+	Endpoint string
+
+	URL string
+}
+
+// Do does something.
+//
+// Deprecated: Do is
+// going away.
+func (c *Client) Do() {}
+
+// Doer does things.
+type Doer interface {
+	// Do does something.
+	//
+	// Deprecated: no longer necessary.
+	Do()
+}
+
+// Deprecated: use string instead.
+type Color string
+
+const (
+	// Deprecated: use "red" instead.
+	ColorRed  Color = "red"
+	ColorBlue Color = "blue"
+)
+
+// Deprecated: this is deprecated.
+func Func() {}
+
+// NotDeprecated mentions "Deprecated: " in the middle of a paragraph.
+func NotDeprecated() {}
+
+type Hoisted = internal.Hoisted
@@ -0,0 +1,27 @@
+package test_diff
+
+// Client is a client.
+type Client struct {
+	Endpoint string
+	Retries  int
+}
+
+// Do does something.
+func (c *Client) Do(name string) error { return nil }
+
+// Removed will be removed.
+func Removed() {}
+
+// Get gets something. Renaming its receiver and parameter doesn't change the API.
+func (c *Client) Get(name string) (string, error) { return "", nil }
+
+type Doer interface {
+	Do(name string) error
+}
+
+type Color string
+
+const ColorRed Color = "red"
+
+// String renames only its receiver, which makes no change.
+func (c Color) String() string { return string(c) }
@@ -0,0 +1,27 @@
+package test_diff
+
+// Client is a client. Changing this comment doesn't change the API.
+type Client struct {
+	Endpoint string
+}
+
+// Do does something.
+func (c *Client) Do(name string, count int) error { return nil }
+
+// Added is new.
+func Added() {}
+
+// Get gets something. Renaming its receiver and parameter doesn't change the API.
+func (client *Client) Get(widgetName string) (string, error) { return "", nil }
+
+type Doer interface {
+	Do(name string) error
+	Undo() error
+}
+
+type Color string
+
+const ColorRed Color = "crimson"
+
+// String renames only its receiver, which makes no change.
+func (col Color) String() string { return string(col) }
@@ -0,0 +1,2 @@
+// Package test_doc has documented APIs.
+package test_doc
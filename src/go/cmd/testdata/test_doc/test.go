@@ -0,0 +1,42 @@
+package test_doc
+
+// Widget is a documented struct.
+type Widget struct {
+	// Name is the widget's name.
+	Name string
+
+	Undocumented int
+}
+
+// NewWidget creates a Widget.
+func NewWidget() *Widget {
+	return &Widget{}
+}
+
+// Spin spins the widget.
+//
+//go:noinline
+func (w *Widget) Spin() {}
+
+// Spinner is a documented interface.
+type Spinner interface {
+	// Spin spins something.
+	Spin()
+}
+
+/*
+Color is a documented simple type.
+*/
+type Color string
+
+const (
+	// ColorRed is red.
+	ColorRed  Color = "red"
+	ColorBlue Color = "blue"
+)
+
+// DefaultColor is the default color.
+var DefaultColor = ColorRed
+
+// Version is a documented untyped const.
+const Version = "v1.0.0"
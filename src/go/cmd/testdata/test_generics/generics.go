@@ -0,0 +1,43 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package test_generics
+
+type Client struct{}
+
+func (c *Client) NewListPager() *Pager[Page] {
+	return nil
+}
+
+type List[T any] []T
+
+type Map[K comparable, V any] map[K]V
+
+type Page struct {
+	Values List[string]
+}
+
+type Pager[T any] struct {
+	current T
+}
+
+func (p *Pager[T]) More() bool {
+	return false
+}
+
+func (p *Pager[T]) NextPage() (T, error) {
+	return p.current, nil
+}
+
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+func (p Pair[K, V]) Swap() Pair[V, K] {
+	return Pair[V, K]{Key: p.Value, Value: p.Key}
+}
+
+type Poller[T any] interface {
+	Result() (T, error)
+}
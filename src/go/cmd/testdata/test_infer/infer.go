@@ -0,0 +1,21 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package test_infer
+
+import "github.com/Azure/azure-sdk-tools/src/go/cmd/testdata/test_infer/sub"
+
+type Options struct{}
+
+var DefaultOptions = NewOptions()
+
+type Color string
+
+const Red = Color("red")
+
+var (
+	Blue           = sub.Color("blue")
+	Count          = int64(3)
+	DefaultTimeout = sub.NewTimeout()
+	Fallback       = fallback()
+)
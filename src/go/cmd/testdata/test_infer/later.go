@@ -0,0 +1,13 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package test_infer
+
+// NewOptions is declared after DefaultOptions
+func NewOptions() Options {
+	return Options{}
+}
+
+func fallback() *Options {
+	return &Options{}
+}
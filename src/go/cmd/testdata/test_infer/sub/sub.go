@@ -0,0 +1,12 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package sub
+
+type Color string
+
+type Timeout int
+
+func NewTimeout() Timeout {
+	return 0
+}
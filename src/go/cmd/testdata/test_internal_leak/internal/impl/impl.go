@@ -0,0 +1,20 @@
+package impl
+
+type Base struct{}
+
+type Part struct{}
+
+type Secret struct{}
+
+type Shared struct{}
+
+// Widget's Part field and Reveal method refer to types the exporting package doesn't alias.
+type Widget struct {
+	Part   Part
+	Shared Shared
+	secret Secret
+}
+
+func (w Widget) Reveal() *Secret {
+	return &w.secret
+}
@@ -0,0 +1,37 @@
+package test_internal_leak
+
+import "github.com/Azure/azure-sdk-tools/src/go/cmd/testdata/test_internal_leak/internal/impl"
+
+type Shared = impl.Shared
+
+type Widget = impl.Widget
+
+var Default impl.Secret
+
+type Getter interface {
+	Get() map[string]impl.Secret
+	Shared() Shared
+}
+
+type Handler func(impl.Secret) error
+
+type Options struct {
+	impl.Base
+	Setting impl.Secret
+	Shared  impl.Shared
+	hidden  impl.Secret
+}
+
+func (o *Options) Secret() impl.Secret {
+	return o.hidden
+}
+
+func New(s impl.Shared) *impl.Secret {
+	return nil
+}
+
+type client struct{}
+
+func (c *client) Secret() impl.Secret {
+	return impl.Secret{}
+}
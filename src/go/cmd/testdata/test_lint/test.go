@@ -0,0 +1,67 @@
+package test_lint
+
+import "context"
+
+// WidgetClient follows the guidelines.
+type WidgetClient struct{}
+
+// NewWidgetClient creates a WidgetClient.
+func NewWidgetClient(endpoint string, options *ClientOptions) (*WidgetClient, error) {
+	return &WidgetClient{}, nil
+}
+
+// ClientOptions configures clients.
+type ClientOptions struct{}
+
+func (c *WidgetClient) Get(ctx context.Context, name string, options *WidgetClientGetOptions) error {
+	return nil
+}
+
+func (c *WidgetClient) NewListPager(options *WidgetClientListOptions) *Pager {
+	return nil
+}
+
+// NewGadgetClient returns a subclient, which needn't take a context or options.
+func (c *WidgetClient) NewGadgetClient() *GadgetClient {
+	return &GadgetClient{}
+}
+
+type WidgetClientGetOptions struct{}
+
+type WidgetClientListOptions struct{}
+
+type Pager struct{}
+
+// GadgetClient violates the guidelines.
+type GadgetClient struct{}
+
+// NewGadgetClient doesn't return an error.
+func NewGadgetClient() *GadgetClient {
+	return &GadgetClient{}
+}
+
+// Delete doesn't take a context.
+func (c *GadgetClient) Delete(name string, options *GadgetClientDeleteOptions) error {
+	return nil
+}
+
+// Update doesn't take options.
+func (c *GadgetClient) Update(ctx context.Context, name string) error {
+	return nil
+}
+
+// Create takes misnamed options.
+func (c *GadgetClient) Create(ctx context.Context, options *CreateOptions) error {
+	return nil
+}
+
+func (c *GadgetClient) unexported(name string) {}
+
+type GadgetClientDeleteOptions struct{}
+
+type CreateOptions struct{}
+
+// Endpoint sends no requests, so it needn't take a context or options.
+func (c *GadgetClient) Endpoint() string {
+	return ""
+}
@@ -1,6 +1,9 @@
+// Package subpackage defines the types exported by test_output.
 package subpackage
 
+// Interface is an interface.
 type Interface interface {
+	// MethodNoReturn doesn't return anything.
 	MethodNoReturn()
 	MethodTwoReturns() (*string, error)
 	MethodOneReturn() *string
@@ -22,13 +25,16 @@ func (s *StructEmpty) UnmarshalJSON([]byte) error {
 	return nil
 }
 
+// StructA is a struct.
 type StructA struct {
+	// Exported is an exported field.
 	Exported       string
 	notExported    string
 	N              int
 	ExportedAsWell string
 }
 
+// NewStructA returns a StructA.
 func NewStructA() StructA {
 	return StructA{notExported: "not exported"}
 }
@@ -91,8 +97,12 @@ func GenericFunctionTwoConstraints[T Interface, U any](t T, u U) (T, U) {
 	return t, u
 }
 
+// Foo is a function.
 func Foo(a StructA) {}
 
+// Bar returns a string.
+//
+// Deprecated: use Foo instead.
 func Bar() (string, error) {
 	return "", nil
 }
@@ -102,6 +112,7 @@ type Enum string
 func (Enum) Method() {}
 
 const (
+	// EnumA is the first Enum value.
 	EnumA  Enum = "A"
 	EnumB  Enum = "B"
 	EnumCD Enum = "CD"
@@ -0,0 +1,8 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package test_platforms
+
+import "example.com/winapi"
+
+type Overlapped = winapi.Overlapped
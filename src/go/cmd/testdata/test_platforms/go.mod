@@ -0,0 +1,7 @@
+module github.com/Azure/azure-sdk-tools/src/go/cmd/testdata/test_platforms
+
+go 1.18
+
+require example.com/winapi v1.0.0
+
+replace example.com/winapi => ./winapi
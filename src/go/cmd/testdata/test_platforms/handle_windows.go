@@ -0,0 +1,14 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package test_platforms
+
+type Handle uintptr
+
+func OpenFile(name string) (Handle, error) {
+	return 0, nil
+}
+
+type Event struct {
+	Handle Handle
+}
@@ -0,0 +1,8 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package winapi
+
+type Overlapped struct {
+	Offset uint32
+}
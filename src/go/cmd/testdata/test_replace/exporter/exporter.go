@@ -0,0 +1,19 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package exporter
+
+import (
+	"example.com/local"
+	"example.com/original"
+	"example.com/original/sub"
+	"example.com/upstream"
+)
+
+type Local = local.Local
+
+type Nested = sub.Nested
+
+type Original = original.Original
+
+type Upstream = upstream.Upstream
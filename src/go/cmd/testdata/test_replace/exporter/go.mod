@@ -0,0 +1,16 @@
+module github.com/Azure/azure-sdk-tools/src/go/cmd/testdata/test_replace/exporter
+
+go 1.18
+
+require (
+	example.com/local v1.0.0
+	example.com/original v1.0.0
+	example.com/upstream v1.0.0
+)
+
+replace example.com/local => ../local
+
+// the replacement declares its own module path
+replace example.com/original v1.0.0 => ../renamed
+
+replace example.com/upstream v1.0.0 => example.com/fork v1.1.0
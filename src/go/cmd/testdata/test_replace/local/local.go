@@ -0,0 +1,9 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package local
+
+type Local struct {
+	// InProgress is an unreleased change
+	InProgress string
+}
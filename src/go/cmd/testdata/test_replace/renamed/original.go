@@ -0,0 +1,8 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package original
+
+import "example.com/renamed/internal/impl"
+
+type Original = impl.Original
@@ -0,0 +1,8 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package sub
+
+type Remote struct {
+	URL string `json:"url"`
+}
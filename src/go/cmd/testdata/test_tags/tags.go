@@ -0,0 +1,17 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package test_tags
+
+import "github.com/Azure/azure-sdk-tools/src/go/cmd/testdata/test_tags/sub"
+
+type Base struct {
+	ID string `json:"id"`
+}
+
+type Model struct {
+	Base
+	*sub.Remote `json:"remote,omitempty"`
+	Count       int
+	Name        string `json:"name" xml:"name,attr"`
+}
@@ -0,0 +1,11 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package test_typecheck
+
+import ctx "context"
+
+// Run refers to a standard library type by an aliased import
+func (c *Client) Run(c2 ctx.Context) error {
+	return nil
+}
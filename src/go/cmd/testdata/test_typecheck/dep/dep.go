@@ -0,0 +1,10 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package dep
+
+type Options struct {
+	Retries int
+}
+
+type Conn struct{}
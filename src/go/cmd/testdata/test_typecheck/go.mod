@@ -0,0 +1,10 @@
+module github.com/Azure/azure-sdk-tools/src/go/cmd/testdata/test_typecheck
+
+go 1.18
+
+require (
+	example.com/cached v1.0.0
+	example.com/dep v1.0.0
+)
+
+replace example.com/dep => ./dep
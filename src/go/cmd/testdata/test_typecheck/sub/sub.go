@@ -0,0 +1,13 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package sub
+
+type Options struct {
+	Retries int
+}
+
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
@@ -0,0 +1,45 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package test_typecheck
+
+import (
+	"net/http"
+
+	. "example.com/cached"
+	"example.com/dep"
+
+	. "github.com/Azure/azure-sdk-tools/src/go/cmd/testdata/test_typecheck/sub"
+	s "github.com/Azure/azure-sdk-tools/src/go/cmd/testdata/test_typecheck/sub"
+)
+
+type Client struct {
+	Options *Options
+	Pairs   Pair[string, Pair[int, *Client]]
+}
+
+// NewClient refers to Options by dot import
+func NewClient(o *Options) *Client {
+	return &Client{Options: o}
+}
+
+// Callback has a func-typed param referring to Options by an aliased import
+func (c *Client) Callback(fn func(o s.Options) error) error {
+	return fn(*c.Options)
+}
+
+// Convert's type param shadows the Client type
+func Convert[Client any](c Client) Client {
+	return c
+}
+
+// Do refers to a standard library type
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+// Connect refers to types from other modules, one replaced by a local directory and the other
+// in the module cache, and has a func-typed param
+func (c *Client) Connect(o dep.Options, l Level, retry func(attempt int) bool) *dep.Conn {
+	return nil
+}
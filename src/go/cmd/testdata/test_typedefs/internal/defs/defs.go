@@ -0,0 +1,31 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package defs
+
+// Labels are key-value pairs.
+type Labels map[string]string
+
+func (l Labels) Has(key string) bool {
+	_, ok := l[key]
+	return ok
+}
+
+// Queue is a channel of work items.
+type Queue chan Item
+
+func (q Queue) Len() int {
+	return len(q)
+}
+
+type Item struct{}
+
+// Options are parenthesized.
+type Options (struct {
+	Retries int
+})
+
+// Source is a parenthesized interface.
+type Source (interface {
+	Read() (Item, error)
+})
@@ -0,0 +1,51 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package test_typedefs
+
+import "github.com/Azure/azure-sdk-tools/src/go/cmd/testdata/test_typedefs/internal/defs"
+
+type Event struct {
+	Name string
+}
+
+// EventPtr is a pointer type.
+type EventPtr *Event
+
+// Events is a channel type.
+type Events <-chan Event
+
+func (e Events) Next() Event {
+	return <-e
+}
+
+// Handler is a parenthesized type.
+type Handler (func(Event) error)
+
+// Headers is a map type.
+type Headers map[string][]string
+
+func (h Headers) Get(key string) string {
+	if v := h[key]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+type Labels = defs.Labels
+
+type Queue = defs.Queue
+
+// Settings is a parenthesized struct.
+type Settings (struct {
+	Verbose bool
+})
+
+// Sink is a parenthesized interface.
+type Sink (interface {
+	Write(e Event) error
+})
+
+type Options = defs.Options
+
+type Source = defs.Source
@@ -0,0 +1,3 @@
+package internal
+
+const moduleVersion = "v0.0.1"
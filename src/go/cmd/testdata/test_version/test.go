@@ -0,0 +1,3 @@
+package test_version
+
+type Client struct{}
@@ -0,0 +1,3 @@
+package test_version
+
+const moduleVersion = "v1.2.3"
@@ -0,0 +1,12 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package test_warnings
+
+// First is an index expression, which reviews don't describe.
+var First = names[0]
+
+// Handlers has a slice type, which reviews don't describe.
+var Handlers []func()
+
+var names = []string{"a", "b"}
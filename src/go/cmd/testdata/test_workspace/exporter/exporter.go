@@ -0,0 +1,13 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package exporter
+
+import (
+	"example.com/other"
+	"example.com/source"
+)
+
+type Other = other.Other
+
+type Source = source.Source
@@ -0,0 +1,11 @@
+module github.com/Azure/azure-sdk-tools/src/go/cmd/testdata/test_workspace/exporter
+
+go 1.18
+
+require (
+	example.com/other v1.0.0
+	example.com/source v1.0.0
+)
+
+// go.work overrides this
+replace example.com/other => ../missing
@@ -0,0 +1,9 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package source
+
+type Source struct {
+	// InProgress is an unreleased change
+	InProgress string
+}
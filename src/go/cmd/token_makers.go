@@ -6,6 +6,9 @@ package cmd
 import (
 	"fmt"
 	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
 	"regexp"
 	"sort"
 	"strings"
@@ -32,19 +35,34 @@ type TokenMaker interface {
 type Declaration struct {
 	Type string
 
-	id    string
-	name  string
-	value string
-}
-
-func NewDeclaration(pkg Pkg, vs *ast.ValueSpec, imports map[string]string) Declaration {
+	// call is the call expression giving the declaration's value, when Type is unknown until
+	// the module is indexed because it's the result of that call
+	call *ast.CallExpr
+	// cv is the value of a const, when known
+	cv      constant.Value
+	doc     *ast.CommentGroup
+	id      string
+	imports map[string]string
+	name    string
+	// result is the index of the call result giving the declaration's value
+	result int
+	// typeTokens has the tokens of Type, when it's described from type information
+	typeTokens typeTokenTable
+	value      string
+}
+
+// NewDeclaration returns a Declaration of the name at index i in vs
+func NewDeclaration(pkg Pkg, vs *ast.ValueSpec, i int, imports map[string]string) Declaration {
 	v := skip
-	if len(vs.Values) > 0 {
-		v = getExprValue(pkg, vs.Values[0])
+	valueExpr := declValue(vs, i)
+	if valueExpr != nil {
+		v = getExprValue(pkg, valueExpr)
 	}
-	decl := Declaration{id: pkg.Name() + "." + vs.Names[0].Name, name: vs.Names[0].Name, value: v}
+	decl := Declaration{doc: vs.Doc, id: pkg.Name() + "." + vs.Names[i].Name, name: vs.Names[i].Name, typeTokens: pkg.typeTokens, value: v}
 	// Type is nil for untyped consts
-	if vs.Type != nil {
+	if t, ok := pkg.typeOf(vs.Type); ok {
+		decl.Type = t
+	} else if vs.Type != nil {
 		switch x := vs.Type.(type) {
 		case *ast.Ident:
 			// const ETagAny ETag = "*"
@@ -65,22 +83,30 @@ func NewDeclaration(pkg Pkg, vs *ast.ValueSpec, imports map[string]string) Decla
 				// var defaultHTTPClient *http.Client
 				decl.Type = pkg.translateType(fmt.Sprintf("*%s.%s", xX.X, xX.Sel.Name), imports)
 			default:
-				fmt.Printf("unhandled declaration type %T for %s\n", xX, pkg.getText(vs.Type.Pos(), vs.Type.End()))
+				pkg.warn(decl.id, vs.Type, fmt.Sprintf("unhandled declaration type %T", xX))
 			}
 		default:
-			fmt.Println("unhandled declaration " + pkg.getText(vs.Type.Pos(), vs.Type.End()))
+			pkg.warn(decl.id, vs.Type, fmt.Sprintf("unhandled declaration type %T", x))
 		}
-	} else if len(vs.Values) == 1 {
-		switch t := vs.Values[0].(type) {
+	} else if valueExpr != nil {
+		switch t := valueExpr.(type) {
 		case *ast.CallExpr:
 			// const FooConst = Foo("value")
 			// var Foo = NewFoo()
-			// TODO: determining the type here requires finding the definition of the called function. We may
-			// not have encountered that yet, so we would need to set the types of these declarations after
-			// traversing the entire AST.
+			if typ, ok := pkg.objectType(vs.Names[i]); ok {
+				decl.Type = typ
+			} else {
+				// determining the type requires finding the definition of the called function, which
+				// may be in a file or package we haven't indexed yet, so Module.inferDeclarationTypes
+				// sets the type after indexing all the module's packages
+				decl.call, decl.imports = t, imports
+				if len(vs.Names) > 1 {
+					decl.result = i
+				}
+			}
 		case *ast.CompositeLit:
 			// var AzureChina = Configuration{ ... }
-			decl.Type = pkg.translateType(pkg.getText(t.Type.Pos(), t.Type.End()), imports)
+			decl.Type = pkg.translateExpr(t.Type, pkg.getText(t.Type.Pos(), t.Type.End()), imports)
 		}
 	} else {
 		// implicitly typed const
@@ -89,6 +115,19 @@ func NewDeclaration(pkg Pkg, vs *ast.ValueSpec, imports map[string]string) Decla
 	return decl
 }
 
+// declValue returns the expression giving the value of the name at index i in vs, or nil when vs
+// has no values. That's the call expression for all names in a spec like "var a, b = f()".
+func declValue(vs *ast.ValueSpec, i int) ast.Expr {
+	switch {
+	case len(vs.Values) == len(vs.Names):
+		return vs.Values[i]
+	case len(vs.Values) == 1:
+		return vs.Values[0]
+	default:
+		return nil
+	}
+}
+
 func (d Declaration) Exported() bool {
 	return unicode.IsUpper(rune(d.name[0]))
 }
@@ -101,6 +140,7 @@ func (d Declaration) MakeTokens() []ReviewToken {
 	rts := []ReviewToken{
 		{
 			HasSuffixSpace:        true,
+			IsDeprecated:          isDeprecated(d.doc),
 			Kind:                  TokenKindTypeName,
 			NavigationDisplayName: d.Name(),
 			NavigateToID:          d.ID(),
@@ -108,7 +148,7 @@ func (d Declaration) MakeTokens() []ReviewToken {
 		},
 	}
 	if d.Type != skip {
-		rts = append(rts, parseAndMakeTypeTokens(d.Type)...)
+		rts = append(rts, d.typeTokens.tokens(d.Type)...)
 	}
 	rts = append(rts, ReviewToken{
 		HasPrefixSpace: true,
@@ -133,6 +173,7 @@ type Func struct {
 	// Returns lists the func's return types
 	Returns []string
 
+	doc      *ast.CommentGroup
 	embedded bool
 	exported bool
 	id       string
@@ -142,18 +183,22 @@ type Func struct {
 	paramNames []string
 	// paramTypes lists the func's parameters type
 	paramTypes []string
-	// typeParamNames lists the func's type parameters name
-	typeParamNames []string
-	// typeParamConstraints lists the func's type parameters constraint
-	typeParamConstraints []string
+	// receiverBase is the name of the receiver's base type e.g. "Pager" for "(p *Pager[T])"
+	receiverBase string
+	// typeParams lists the func's type parameters
+	typeParams []typeParam
+	// typeTokens has the tokens of the func's types described from type information
+	typeTokens typeTokenTable
 }
 
 func NewFunc(pkg Pkg, f *ast.FuncDecl, imports map[string]string) Func {
 	fn := newFunc(pkg, f.Type, imports)
+	fn.doc = f.Doc
 	fn.name = f.Name.Name
 	sig := ""
 	if f.Recv != nil {
 		fn.ReceiverType = pkg.getText(f.Recv.List[0].Type.Pos(), f.Recv.List[0].Type.End())
+		fn.receiverBase = receiverBase(f.Recv.List[0].Type)
 		if len(f.Recv.List[0].Names) != 0 {
 			fn.ReceiverName = f.Recv.List[0].Names[0].Name
 		}
@@ -171,6 +216,7 @@ func NewFunc(pkg Pkg, f *ast.FuncDecl, imports map[string]string) Func {
 
 func NewFuncForInterfaceMethod(pkg Pkg, interfaceName string, f *ast.Field, imports map[string]string) Func {
 	fn := newFunc(pkg, f.Type.(*ast.FuncType), imports)
+	fn.doc = f.Doc
 	fn.name = f.Names[0].Name
 	fn.exported = unicode.IsUpper(rune(fn.name[0]))
 	fn.id = pkg.Name() + "-" + interfaceName + "-" + fn.name
@@ -179,31 +225,24 @@ func NewFuncForInterfaceMethod(pkg Pkg, interfaceName string, f *ast.Field, impo
 }
 
 func newFunc(pkg Pkg, f *ast.FuncType, imports map[string]string) Func {
-	fn := Func{}
-	if f.TypeParams != nil {
-		fn.typeParamNames = make([]string, 0, len(f.TypeParams.List))
-		fn.typeParamConstraints = make([]string, 0, len(f.TypeParams.List))
-		pkg.translateFieldList(f.TypeParams.List, func(param *string, constraint string) {
-			fn.typeParamNames = append(fn.typeParamNames, *param)
-			fn.typeParamConstraints = append(fn.typeParamConstraints, pkg.translateType(strings.TrimRight(constraint, " "), imports))
-		})
-	}
+	fn := Func{typeTokens: pkg.typeTokens}
+	fn.typeParams = newTypeParams(pkg, f.TypeParams, imports)
 	if f.Params.List != nil {
 		fn.paramNames = make([]string, 0, len(f.Params.List))
 		fn.paramTypes = make([]string, 0, len(f.Params.List))
-		pkg.translateFieldList(f.Params.List, func(n *string, t string) {
+		pkg.translateFieldList(f.Params.List, func(n *string, t string, x ast.Expr) {
 			if n != nil {
 				fn.paramNames = append(fn.paramNames, *n)
 			} else {
 				fn.paramNames = append(fn.paramNames, "")
 			}
-			fn.paramTypes = append(fn.paramTypes, pkg.translateType(t, imports))
+			fn.paramTypes = append(fn.paramTypes, pkg.translateExpr(x, t, imports))
 		})
 	}
 	if f.Results != nil {
 		fn.Returns = make([]string, 0, len(f.Results.List))
-		pkg.translateFieldList(f.Results.List, func(n *string, t string) {
-			fn.Returns = append(fn.Returns, pkg.translateType(t, imports))
+		pkg.translateFieldList(f.Results.List, func(n *string, t string, x ast.Expr) {
+			fn.Returns = append(fn.Returns, pkg.translateExpr(x, t, imports))
 		})
 	}
 	return fn
@@ -251,7 +290,14 @@ func (f Func) MakeTokens() []ReviewToken {
 			Kind:  TokenKindText,
 			Value: "(",
 		})
-		tks = append(tks, parseAndMakeTypeTokens(f.ReceiverType)...)
+		for _, tk := range parseAndMakeTypeTokens(f.ReceiverType) {
+			if tk.Value == f.receiverBase {
+				// link to the receiver's type, which is in the package identified by the prefix of f's ID
+				pkgName, _, _ := strings.Cut(f.id, "-")
+				tk.NavigateToID = pkgName + "." + f.receiverBase
+			}
+			tks = append(tks, tk)
+		}
 		tks = append(tks, ReviewToken{
 			HasSuffixSpace: true,
 			Kind:           TokenKindPunctuation,
@@ -259,34 +305,11 @@ func (f Func) MakeTokens() []ReviewToken {
 		})
 	}
 	tks = append(tks, ReviewToken{
-		Kind:  TokenKindTypeName,
-		Value: f.name,
+		IsDeprecated: isDeprecated(f.doc),
+		Kind:         TokenKindTypeName,
+		Value:        f.name,
 	})
-	if len(f.typeParamNames) > 0 {
-		tks = append(tks, ReviewToken{
-			Kind:  TokenKindPunctuation,
-			Value: "[",
-		})
-		for i, p := range f.typeParamNames {
-			if i > 0 {
-				tks = append(tks, ReviewToken{
-					HasSuffixSpace: true,
-					Kind:           TokenKindPunctuation,
-					Value:          ",",
-				})
-			}
-			tks = append(tks, ReviewToken{
-				HasSuffixSpace: true,
-				Kind:           TokenKindMemberName,
-				Value:          p,
-			})
-			tks = append(tks, parseAndMakeTypeTokens(f.typeParamConstraints[i])...)
-		}
-		tks = append(tks, ReviewToken{
-			Kind:  TokenKindPunctuation,
-			Value: "]",
-		})
-	}
+	tks = append(tks, makeTypeParamTokens(f.typeParams, f.typeTokens)...)
 	paren := "("
 	if len(f.paramNames) == 0 {
 		paren += ")"
@@ -302,10 +325,10 @@ func (f Func) MakeTokens() []ReviewToken {
 				Kind:           TokenKindMemberName,
 				Value:          p,
 			})
-			tks = append(tks, parseAndMakeTypeTokens(f.paramTypes[i])...)
+			tks = append(tks, f.typeTokens.tokens(f.paramTypes[i])...)
 		} else {
 			// parameter names are optional
-			tks = append(tks, parseAndMakeTypeTokens(f.paramTypes[i])...)
+			tks = append(tks, f.typeTokens.tokens(f.paramTypes[i])...)
 		}
 		if i < len(f.paramNames)-1 {
 			tks = append(tks, ReviewToken{
@@ -332,7 +355,7 @@ func (f Func) MakeTokens() []ReviewToken {
 			tks[len(tks)-1].HasSuffixSpace = true
 		}
 		for i, t := range f.Returns {
-			tks = append(tks, parseAndMakeTypeTokens(t)...)
+			tks = append(tks, f.typeTokens.tokens(t)...)
 			if i < len(f.Returns)-1 {
 				tks = append(tks, ReviewToken{
 					HasSuffixSpace: true,
@@ -361,19 +384,31 @@ type Interface struct {
 	TokenMaker
 	// Sealed indicates whether users can implement the interface i.e. whether it has an unexported method
 	Sealed             bool
+	doc                *ast.CommentGroup
 	embeddedInterfaces []string
 	id                 string
 	methods            map[string]Func
 	name               string
+	typeParams         []typeParam
+	// typeTerms are the interface's type set elements e.g. "~int | ~string" and "comparable", in source
+	// order. An interface having any is a constraint, usable only in type parameter lists.
+	typeTerms []string
+	// typeTokens has the tokens of the interface's types described from type information
+	typeTokens typeTokenTable
 }
 
-func NewInterface(source Pkg, name, packageName string, n *ast.InterfaceType, imports map[string]string) Interface {
+func NewInterface(source Pkg, name, packageName string, ts *ast.TypeSpec, imports map[string]string) Interface {
 	in := Interface{
+		doc:                ts.Doc,
 		name:               name,
 		embeddedInterfaces: []string{},
 		methods:            map[string]Func{},
 		id:                 packageName + "." + name,
+		typeParams:         newTypeParams(source, ts.TypeParams, imports),
+		typeTokens:         source.typeTokens,
 	}
+	// the type may be parenthesized, as in "type I (interface{ M() })"
+	n := unparen(ts.Type).(*ast.InterfaceType)
 	if n.Methods != nil {
 		for _, m := range n.Methods.List {
 			if len(m.Names) > 0 {
@@ -383,9 +418,10 @@ func NewInterface(source Pkg, name, packageName string, n *ast.InterfaceType, im
 				}
 				f := NewFuncForInterfaceMethod(source, name, m, imports)
 				in.methods[n] = f
+			} else if n := source.getText(m.Type.Pos(), m.Type.End()); source.isTypeTerm(m.Type) {
+				in.typeTerms = append(in.typeTerms, source.translateExpr(m.Type, n, imports))
 			} else {
-				n := source.getText(m.Type.Pos(), m.Type.End())
-				in.embeddedInterfaces = append(in.embeddedInterfaces, source.translateType(n, imports))
+				in.embeddedInterfaces = append(in.embeddedInterfaces, source.translateExpr(m.Type, n, imports))
 			}
 		}
 	}
@@ -393,6 +429,33 @@ func NewInterface(source Pkg, name, packageName string, n *ast.InterfaceType, im
 	return in
 }
 
+// isTypeTerm returns true when x, an element of an interface having no name, is a type set element
+// such as a union, "~T", "comparable" or a type other than an interface, rather than an embedded interface.
+// When the package has been type checked, isTypeTerm classifies named types by their type information.
+// Otherwise it can't tell what a name refers to, so it assumes names other than predeclared types name
+// interfaces and misclassifies a defined non-interface type such as MyInt in "interface{ MyInt }".
+func (pkg Pkg) isTypeTerm(x ast.Expr) bool {
+	switch t := x.(type) {
+	case *ast.BinaryExpr:
+		return t.Op == token.OR
+	case *ast.Ident, *ast.SelectorExpr:
+		if pkg.info != nil {
+			if tv, ok := pkg.info.Types[x]; ok && tv.Type != nil && tv.Type != types.Typ[types.Invalid] {
+				return !types.IsInterface(tv.Type) || tv.Type == types.Universe.Lookup("comparable").Type()
+			}
+		}
+		id, ok := t.(*ast.Ident)
+		return ok && id.Name != "any" && id.Name != "error" && slices.Contains(internalTypes, id.Name)
+	case *ast.ParenExpr:
+		return pkg.isTypeTerm(t.X)
+	case *ast.UnaryExpr:
+		return t.Op == token.TILDE
+	case *ast.ArrayType, *ast.ChanType, *ast.FuncType, *ast.MapType, *ast.StarExpr, *ast.StructType:
+		return true
+	}
+	return false
+}
+
 func (i Interface) Exported() bool {
 	return unicode.IsUpper(rune(i.name[0]))
 }
@@ -412,24 +475,30 @@ func (i Interface) MakeReviewLine() ReviewLine {
 			},
 			{
 				HasPrefixSpace:        true,
-				HasSuffixSpace:        true,
+				IsDeprecated:          isDeprecated(i.doc),
 				Kind:                  TokenKindTypeName,
 				NavigationDisplayName: i.id,
 				Value:                 i.name,
 			},
-			{
-				Kind:  TokenKindKeyword,
-				Value: "interface",
-			},
 		},
 	}
+	interfaceLine.Tokens = append(interfaceLine.Tokens, makeTypeParamTokens(i.typeParams, i.typeTokens)...)
+	interfaceLine.Tokens = append(interfaceLine.Tokens, ReviewToken{
+		HasPrefixSpace: true,
+		Kind:           TokenKindKeyword,
+		Value:          "interface",
+	})
 
+	for _, term := range i.typeTerms {
+		interfaceLine.Children = append(interfaceLine.Children, ReviewLine{
+			Tokens: i.typeTokens.tokens(term),
+		})
+	}
 	for _, name := range i.embeddedInterfaces {
-		if exportedFieldRgx.MatchString(name) {
-			// TODO: set NavigateToID to create a navigation link. Need the type's LineID i.e. qualified
-			// name but we don't have it here; similar to https://github.com/Azure/azure-sdk-tools/issues/6150
+		// name has a navigation mark when the embedded interface is in this module e.g. "<azcore.Thing>Thing"
+		if exportedFieldRgx.MatchString(removeNavigatorString(name)) {
 			interfaceLine.Children = append(interfaceLine.Children, ReviewLine{
-				Tokens: parseAndMakeTypeTokens(name),
+				Tokens: i.typeTokens.tokens(name),
 			})
 		}
 	}
@@ -447,6 +516,7 @@ func (i Interface) MakeReviewLine() ReviewLine {
 				LineID: i.id + "-" + k,
 				Tokens: i.methods[k].MakeTokens(),
 			}
+			interfaceLine.Children = append(interfaceLine.Children, makeDocLines(i.methods[k].doc, methodLine.LineID)...)
 			interfaceLine.Children = append(interfaceLine.Children, methodLine)
 		}
 	}
@@ -461,8 +531,12 @@ func (i Interface) Name() string {
 var _ TokenMaker = (*Interface)(nil)
 
 type SimpleType struct {
-	id             string
-	name           string
+	doc        *ast.CommentGroup
+	id         string
+	name       string
+	typeParams []typeParam
+	// typeTokens has the tokens of the underlying type, when it's described from type information
+	typeTokens     typeTokenTable
 	underlyingType string
 }
 
@@ -486,13 +560,15 @@ func (s SimpleType) MakeTokens() []ReviewToken {
 		},
 		{
 			HasPrefixSpace:        true,
-			HasSuffixSpace:        true,
+			IsDeprecated:          isDeprecated(s.doc),
 			Kind:                  TokenKindTypeName,
 			NavigationDisplayName: s.id,
 			Value:                 s.name,
 		},
 	}
-	tks = append(tks, parseAndMakeTypeTokens(s.underlyingType)...)
+	tks = append(tks, makeTypeParamTokens(s.typeParams, s.typeTokens)...)
+	tks[len(tks)-1].HasSuffixSpace = true
+	tks = append(tks, s.typeTokens.tokens(s.underlyingType)...)
 	return tks
 }
 
@@ -504,13 +580,23 @@ var _ TokenMaker = (*SimpleType)(nil)
 
 type Struct struct {
 	AnonymousFields []string
+	doc             *ast.CommentGroup
+	// embeddedTypes maps each of AnonymousFields to its translated type
+	embeddedTypes map[string]string
+	// fieldDocs maps a field's name to its doc comment
+	fieldDocs map[string]*ast.CommentGroup
+	// fieldTags maps a field's name, or an anonymous field's type, to the field's tag as written
+	// in source e.g. `json:"name,omitempty"`
+	fieldTags map[string]string
 	// fields maps a field's name to the name of its type
 	fields map[string]string
 	id     string
 	name   string
-	// typeParams lists the func's type parameters as strings of the form "name constraint"
-	typeParams []string
+	// typeParams lists the struct's type parameters
+	typeParams []typeParam
 	pkgName    string
+	// typeTokens has the tokens of the struct's types described from type information
+	typeTokens typeTokenTable
 }
 
 func (s Struct) MakeReviewLine() ReviewLine {
@@ -520,17 +606,12 @@ func (s Struct) MakeReviewLine() ReviewLine {
 		Tokens:   s.MakeTokens(),
 	}
 	for _, field := range s.AnonymousFields {
-		if exportedFieldRgx.MatchString(field) {
-			structLine.Children = append(structLine.Children, ReviewLine{
-				Tokens: []ReviewToken{
-					{
-						Kind: TokenKindTypeName,
-						// TODO: set NavigateToID to create a navigation link. Need the type's LineID i.e. qualified
-						// name but we don't have it here https://github.com/Azure/azure-sdk-tools/issues/6150
-						Value: field,
-					},
-				},
-			})
+		if exportedFieldRgx.MatchString(strings.TrimPrefix(field, "*")) {
+			tks := s.typeTokens.tokens(s.embeddedTypes[field])
+			if tag, ok := s.fieldTags[field]; ok {
+				tks = append(tks, makeTagToken(tag))
+			}
+			structLine.Children = append(structLine.Children, ReviewLine{Tokens: tks})
 		}
 	}
 	exported := []string{}
@@ -550,8 +631,9 @@ func (s Struct) MakeReviewLine() ReviewLine {
 				LineID: s.id + "-" + name,
 				Tokens: []ReviewToken{
 					{
-						Kind:  TokenKindText,
-						Value: name,
+						IsDeprecated: isDeprecated(s.fieldDocs[name]),
+						Kind:         TokenKindText,
+						Value:        name,
 					},
 					{
 						Kind:     TokenKindText,
@@ -562,8 +644,12 @@ func (s Struct) MakeReviewLine() ReviewLine {
 					},
 				},
 			}
-			typeTks := parseAndMakeTypeTokens(s.fields[name])
+			typeTks := s.typeTokens.tokens(s.fields[name])
 			fieldLine.Tokens = append(fieldLine.Tokens, typeTks...)
+			if tag, ok := s.fieldTags[name]; ok {
+				fieldLine.Tokens = append(fieldLine.Tokens, makeTagToken(tag))
+			}
+			structLine.Children = append(structLine.Children, makeDocLines(s.fieldDocs[name], fieldLine.LineID)...)
 			structLine.Children = append(structLine.Children, fieldLine)
 		}
 	}
@@ -571,23 +657,41 @@ func (s Struct) MakeReviewLine() ReviewLine {
 }
 
 func NewStruct(source Pkg, name, packageName string, ts *ast.TypeSpec, imports map[string]string) Struct {
-	s := Struct{name: name, id: packageName + "." + name, pkgName: source.Name()}
-	if ts.TypeParams != nil {
-		s.typeParams = make([]string, 0, len(ts.TypeParams.List))
-		source.translateFieldList(ts.TypeParams.List, func(param *string, constraint string) {
-			s.typeParams = append(s.typeParams, strings.TrimRight(*param+" "+source.translateType(constraint, imports), " "))
-		})
-	}
-	source.translateFieldList(ts.Type.(*ast.StructType).Fields.List, func(n *string, t string) {
+	s := Struct{
+		doc:           ts.Doc,
+		embeddedTypes: map[string]string{},
+		fieldDocs:     map[string]*ast.CommentGroup{},
+		fieldTags:     map[string]string{},
+		name:          name,
+		id:            packageName + "." + name,
+		pkgName:       source.Name(),
+		typeTokens:    source.typeTokens,
+	}
+	s.typeParams = newTypeParams(source, ts.TypeParams, imports)
+	// the type may be parenthesized, as in "type S (struct{ A int })"
+	st := unparen(ts.Type).(*ast.StructType)
+	source.translateFieldList(st.Fields.List, func(n *string, t string, x ast.Expr) {
 		if n == nil {
 			s.AnonymousFields = append(s.AnonymousFields, t)
+			s.embeddedTypes[t] = source.translateExpr(x, t, imports)
 		} else {
 			if s.fields == nil {
 				s.fields = map[string]string{}
 			}
-			s.fields[*n] = source.translateType(t, imports)
+			s.fields[*n] = source.translateExpr(x, t, imports)
 		}
 	})
+	for _, f := range st.Fields.List {
+		for _, n := range f.Names {
+			s.fieldDocs[n.Name] = f.Doc
+			if f.Tag != nil {
+				s.fieldTags[n.Name] = f.Tag.Value
+			}
+		}
+		if len(f.Names) == 0 && f.Tag != nil {
+			s.fieldTags[source.getText(f.Type.Pos(), f.Type.End())] = f.Tag.Value
+		}
+	}
 	sort.Strings(s.AnonymousFields)
 	return s
 }
@@ -608,16 +712,13 @@ func (s Struct) MakeTokens() []ReviewToken {
 			Value:          "type",
 		},
 		{
+			IsDeprecated:          isDeprecated(s.doc),
 			Kind:                  TokenKindTypeName,
 			NavigationDisplayName: s.id,
 			Value:                 s.name,
 		},
 	}
-	if len(s.typeParams) > 0 {
-		rts = append(rts, ReviewToken{Kind: TokenKindPunctuation, Value: "["})
-		rts = append(rts, ReviewToken{Kind: TokenKindTypeName, Value: strings.Join(s.typeParams, ", ")})
-		rts = append(rts, ReviewToken{Kind: TokenKindPunctuation, Value: "]"})
-	}
+	rts = append(rts, makeTypeParamTokens(s.typeParams, s.typeTokens)...)
 	rts = append(rts, ReviewToken{
 		HasPrefixSpace: true,
 		Kind:           TokenKindKeyword,
@@ -637,7 +738,7 @@ func parseAndMakeTypeTokens(val string) []ReviewToken {
 	now := ""
 	for _, r := range val {
 		switch s := string(r); s {
-		case "*", "[", "]", " ", "(", ")", "{", "}", ",":
+		case "*", "[", "]", " ", "(", ")", "{", "}", ",", "|", "~":
 			if now != "" {
 				toks = append(toks, makeTypeSectionToken(now))
 				now = ""
@@ -664,8 +765,133 @@ func parseAndMakeTypeTokens(val string) []ReviewToken {
 	return toks
 }
 
-var keywords = []string{"interface", "map", "any", "func"}
-var internalTypes = []string{"bool", "uint8", "uint16", "uint32", "uint64", "uint", "int8", "int16", "int32", "int64", "int", "float32", "float64", "complex64", "complex128", "byte", "rune", "string", "error", "uintptr", "nil"}
+// typeParam is a type parameter of a generic type or func
+type typeParam struct {
+	// constraint is the parameter's translated constraint e.g. "any" or "<azcore.Thing>Thing"
+	constraint string
+	name       string
+}
+
+// newTypeParams returns the type parameters declared by fl, which is nil for non-generic declarations
+func newTypeParams(pkg Pkg, fl *ast.FieldList, imports map[string]string) []typeParam {
+	if fl == nil {
+		return nil
+	}
+	tps := make([]typeParam, 0, len(fl.List))
+	pkg.translateFieldList(fl.List, func(param *string, constraint string, x ast.Expr) {
+		tps = append(tps, typeParam{constraint: pkg.translateExpr(x, strings.TrimRight(constraint, " "), imports), name: *param})
+	})
+	return tps
+}
+
+// makeTypeParamTokens returns tokens for a list of type parameters such as "[K comparable, V any]",
+// taking the tokens of constraints described from type information from tt. It returns no tokens
+// when tps is empty.
+func makeTypeParamTokens(tps []typeParam, tt typeTokenTable) []ReviewToken {
+	if len(tps) == 0 {
+		return nil
+	}
+	tks := []ReviewToken{{Kind: TokenKindPunctuation, Value: "["}}
+	for i, p := range tps {
+		if i > 0 {
+			tks = append(tks, ReviewToken{
+				HasSuffixSpace: true,
+				Kind:           TokenKindPunctuation,
+				Value:          ",",
+			})
+		}
+		tks = append(tks, ReviewToken{
+			HasSuffixSpace: true,
+			Kind:           TokenKindMemberName,
+			Value:          p.name,
+		})
+		tks = append(tks, tt.tokens(p.constraint)...)
+	}
+	return append(tks, ReviewToken{Kind: TokenKindPunctuation, Value: "]"})
+}
+
+// receiverBase returns the name of a method receiver's base type e.g. "Pager" for "*Pager[T]"
+func receiverBase(x ast.Expr) string {
+	for {
+		switch t := x.(type) {
+		case *ast.Ident:
+			return t.Name
+		case *ast.IndexExpr:
+			x = t.X
+		case *ast.IndexListExpr:
+			x = t.X
+		case *ast.ParenExpr:
+			x = t.X
+		case *ast.StarExpr:
+			x = t.X
+		default:
+			return ""
+		}
+	}
+}
+
+// makeTagToken returns a token for a struct field's tag. Tags are part of the API because they
+// determine wire formats, however reviews may exclude them from diffs with --skip-diff-tags.
+func makeTagToken(tag string) ReviewToken {
+	return ReviewToken{
+		HasPrefixSpace: true,
+		Kind:           TokenKindStringLiteral,
+		SkipDiff:       skipDiffTags,
+		Value:          tag,
+	}
+}
+
+// deprecationNotice returns the "Deprecated:" paragraph of the given doc comment, or an empty
+// string when the comment has no such paragraph. See https://go.dev/wiki/Deprecated.
+func deprecationNotice(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	for _, para := range strings.Split(doc.Text(), "\n\n") {
+		if para = strings.TrimSpace(para); strings.HasPrefix(para, "Deprecated: ") {
+			return strings.Join(strings.Fields(para), " ")
+		}
+	}
+	return ""
+}
+
+// isDeprecated returns true when the given doc comment has a "Deprecated:" paragraph
+func isDeprecated(doc *ast.CommentGroup) bool {
+	return deprecationNotice(doc) != ""
+}
+
+// directiveRgx matches directive comments such as "//go:generate" and "//nolint:errcheck"
+var directiveRgx = regexp.MustCompile(`^//[a-z0-9]+:[a-z0-9]`)
+
+// makeDocLines returns a documentation ReviewLine for each line of the given doc comment. APIView
+// hides documentation by default, and hides these lines along with the line identified by relatedTo.
+func makeDocLines(doc *ast.CommentGroup, relatedTo string) []ReviewLine {
+	lns := []ReviewLine{}
+	if doc == nil {
+		return lns
+	}
+	for _, c := range doc.List {
+		if directiveRgx.MatchString(c.Text) {
+			continue
+		}
+		for _, txt := range strings.Split(c.Text, "\n") {
+			lns = append(lns, ReviewLine{
+				RelatedToLine: relatedTo,
+				Tokens: []ReviewToken{
+					{
+						IsDocumentation: true,
+						Kind:            TokenKindComment,
+						Value:           strings.TrimRight(txt, " \t\r"),
+					},
+				},
+			})
+		}
+	}
+	return lns
+}
+
+var keywords = []string{"interface", "map", "any", "func", "chan", "<-chan", "chan<-"}
+var internalTypes = []string{"bool", "comparable", "uint8", "uint16", "uint32", "uint64", "uint", "int8", "int16", "int32", "int64", "int", "float32", "float64", "complex64", "complex128", "byte", "rune", "string", "error", "uintptr", "nil"}
 
 func makeTypeSectionToken(section string) ReviewToken {
 	switch {
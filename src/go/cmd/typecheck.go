@@ -0,0 +1,534 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	"golang.org/x/mod/module"
+)
+
+// moduleImporter imports packages for the type checker. It type checks packages of the module being
+// indexed, and of the modules it requires, from source and imports standard library packages from
+// export data. It finds the source of required modules as the go command would, in directories given
+// by go.work and replace directives or else in the Go module cache. When a required module isn't in
+// the cache, the types of identifiers declared in its packages are invalid and indexing handles those
+// the same as it would without type checking.
+type moduleImporter struct {
+	checked map[string]*types.Package
+	// ctx selects the files of packages in other modules
+	ctx *build.Context
+	// deps are the modules the indexed module requires
+	deps []module.Version
+	// failed are the paths of required modules whose source wasn't found
+	failed map[string]bool
+	// fs has the positions of the files of packages in other modules
+	fs   *token.FileSet
+	pkgs map[string]*Pkg
+	std  types.Importer
+	// workspace locates the source of deps. It's nil when the indexed module's go.work is invalid.
+	workspace *workspace
+}
+
+// typeCheck type checks the module's packages, recording in each the type information translateExpr
+// uses to describe type expressions. dir is the module's directory and ctx matches the files indexed.
+// Type errors, including failures to import packages from other modules, don't stop type checking
+// because the checker records what it can despite errors.
+func (m *Module) typeCheck(dir string, ctx *build.Context) {
+	mi := &moduleImporter{
+		checked: map[string]*types.Package{},
+		ctx:     ctx,
+		failed:  map[string]bool{},
+		fs:      token.NewFileSet(),
+		pkgs:    map[string]*Pkg{},
+		std:     importer.Default(),
+	}
+	for _, r := range m.ModFile.Require {
+		mi.deps = append(mi.deps, r.Mod)
+	}
+	w, err := loadWorkspace(dir, m.ModFile)
+	if err != nil {
+		logger.Warn("can't type check imports from other modules", "err", err)
+	}
+	mi.workspace = w
+	for _, p := range m.Packages {
+		mi.pkgs[p.importPath] = p
+	}
+	paths := make([]string, 0, len(mi.pkgs))
+	for impPath := range mi.pkgs {
+		paths = append(paths, impPath)
+	}
+	sort.Strings(paths)
+	for _, impPath := range paths {
+		// this checks the package's imports too, if they're not already checked
+		_, _ = mi.Import(impPath)
+	}
+}
+
+func (mi *moduleImporter) Import(impPath string) (*types.Package, error) {
+	if tp, ok := mi.checked[impPath]; ok {
+		return tp, nil
+	}
+	p, ok := mi.pkgs[impPath]
+	if !ok {
+		// standard library import paths have no dot in their first element
+		if first, _, _ := strings.Cut(impPath, "/"); !strings.Contains(first, ".") {
+			return mi.std.Import(impPath)
+		}
+		return mi.importDependency(impPath)
+	}
+	names := make([]string, 0, len(p.p.Files))
+	for name := range p.p.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	files := make([]*ast.File, 0, len(names))
+	for _, name := range names {
+		files = append(files, p.p.Files[name])
+	}
+	conf := types.Config{
+		Error:    func(error) {},
+		Importer: mi,
+	}
+	info := &types.Info{
+		Defs:  map[*ast.Ident]types.Object{},
+		Uses:  map[*ast.Ident]types.Object{},
+		Types: map[ast.Expr]types.TypeAndValue{},
+	}
+	// Check returns the first error, which we ignore for the reason given above
+	tp, _ := conf.Check(impPath, p.fs, files, info)
+	p.info = info
+	mi.checked[impPath] = tp
+	return tp, nil
+}
+
+// importDependency type checks the package having import path impPath in one of the modules the
+// indexed module requires
+func (mi *moduleImporter) importDependency(impPath string) (*types.Package, error) {
+	dir, err := mi.packageDir(impPath)
+	if err != nil {
+		return nil, err
+	}
+	var matchErr error
+	pkgs, err := parser.ParseDir(mi.fs, dir, func(f fs.FileInfo) bool {
+		if strings.HasSuffix(f.Name(), "_test.go") {
+			return false
+		}
+		match, err := mi.ctx.MatchFile(dir, f.Name())
+		if err != nil && matchErr == nil {
+			matchErr = err
+		}
+		return match
+	}, parser.SkipObjectResolution)
+	if err == nil {
+		err = matchErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("found %d packages in %s", len(pkgs), dir)
+	}
+	files := []*ast.File{}
+	for _, p := range pkgs {
+		names := make([]string, 0, len(p.Files))
+		for name := range p.Files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			files = append(files, p.Files[name])
+		}
+	}
+	conf := types.Config{
+		Error: func(error) {},
+		// declarations are all the indexed module needs from its dependencies
+		IgnoreFuncBodies: true,
+		Importer:         mi,
+	}
+	tp, _ := conf.Check(impPath, mi.fs, files, nil)
+	mi.checked[impPath] = tp
+	return tp, nil
+}
+
+// packageDir returns the directory of the package having import path impPath in one of the modules
+// the indexed module requires. When there are several, that's the module having the longest path,
+// as with the go command.
+func (mi *moduleImporter) packageDir(impPath string) (string, error) {
+	var dep module.Version
+	for _, d := range mi.deps {
+		if (impPath == d.Path || strings.HasPrefix(impPath, d.Path+"/")) && len(d.Path) > len(dep.Path) {
+			dep = d
+		}
+	}
+	if dep.Path == "" {
+		return "", fmt.Errorf("no required module provides package %s", impPath)
+	}
+	rel := filepath.FromSlash(strings.TrimPrefix(impPath, dep.Path))
+	if mi.workspace != nil {
+		dir, mod := mi.workspace.locate(dep)
+		if dir != "" {
+			return filepath.Join(dir, rel), nil
+		}
+		dep = mod
+	}
+	root := filepath.Join(goModCache(), mustEscape(dep.Path)) + "@" + dep.Version
+	if _, err := os.Stat(root); err != nil {
+		if !mi.failed[dep.Path] {
+			mi.failed[dep.Path] = true
+			logger.Warn("module isn't in the module cache, so its types aren't type checked; run \"go mod download\" to add it", "module", dep)
+		}
+		return "", err
+	}
+	return filepath.Join(root, rel), nil
+}
+
+// goModCache returns the directory of the Go module cache, which is $GOMODCACHE or else the pkg/mod
+// directory in the first element of GOPATH
+func goModCache() string {
+	if d := os.Getenv("GOMODCACHE"); d != "" {
+		return d
+	}
+	return filepath.Join(filepath.SplitList(build.Default.GOPATH)[0], "pkg", "mod")
+}
+
+// typeOf returns the text of x's type, with navigation marks for named types declared in the package's
+// module. It returns false when the package hasn't been type checked or x's type isn't known.
+func (pkg Pkg) typeOf(x ast.Expr) (string, bool) {
+	if pkg.info == nil || x == nil {
+		return "", false
+	}
+	w := typeWriter{imports: pkg.importsAt(x.Pos()), pkg: pkg, qualifiers: pkg.qualifiersIn(x)}
+	if e, ok := x.(*ast.Ellipsis); ok {
+		// a variadic parameter
+		w.add(TokenKindPunctuation, "...")
+		x = e.Elt
+	}
+	tv, ok := pkg.info.Types[x]
+	if !ok || tv.Type == nil || strings.Contains(types.TypeString(tv.Type, nil), "invalid type") {
+		return "", false
+	}
+	w.writeType(tv.Type)
+	return pkg.typeTokens.record(w.tks), true
+}
+
+// objectType returns the text of the type of the object ident declares, like typeOf. It returns false when
+// the package hasn't been type checked, the type isn't known or the object is an untyped constant.
+func (pkg Pkg) objectType(ident *ast.Ident) (string, bool) {
+	if pkg.info == nil {
+		return "", false
+	}
+	obj := pkg.info.Defs[ident]
+	if obj == nil || strings.Contains(types.TypeString(obj.Type(), nil), "invalid type") {
+		return "", false
+	}
+	if b, ok := obj.Type().(*types.Basic); ok && b.Info()&types.IsUntyped != 0 {
+		return "", false
+	}
+	w := typeWriter{imports: pkg.importsAt(ident.Pos()), pkg: pkg}
+	w.writeType(obj.Type())
+	return pkg.typeTokens.record(w.tks), true
+}
+
+// typeTokenTable maps the texts of types described from type information to the tokens of those types.
+// Indexing describes types as text, which TokenMakers turn back into tokens. Recording the tokens built
+// from each type's types.Type lets TokenMakers use them instead of parsing the text.
+type typeTokenTable map[string][]ReviewToken
+
+// record adds tks to the table, returning their text
+func (tt typeTokenTable) record(tks []ReviewToken) string {
+	sb := strings.Builder{}
+	for _, tk := range tks {
+		if tk.NavigateToID != "" {
+			sb.WriteString("<" + tk.NavigateToID + ">")
+		}
+		sb.WriteString(tk.Value)
+		if tk.HasSuffixSpace {
+			sb.WriteString(" ")
+		}
+	}
+	tt[sb.String()] = tks
+	return sb.String()
+}
+
+// tokens returns the tokens of the type having the given text. When the table doesn't have that text,
+// because the type was described without type information, it parses the text.
+func (tt typeTokenTable) tokens(text string) []ReviewToken {
+	if tks, ok := tt[text]; ok {
+		return slices.Clone(tks)
+	}
+	return parseAndMakeTypeTokens(text)
+}
+
+// typeWriter builds the tokens of types as they would appear in pkg's source
+type typeWriter struct {
+	// imports maps the names of the packages imported by the file declaring the types to their paths
+	imports map[string]string
+	pkg     Pkg
+	// qualifiers maps the paths of packages to the names qualifying them in the expression described,
+	// which are empty for dot imports
+	qualifiers map[string]string
+	tks        []ReviewToken
+}
+
+func (w *typeWriter) add(kind TokenKind, value string) {
+	w.tks = append(w.tks, ReviewToken{Kind: kind, Value: value})
+}
+
+// space adds a space after the last token
+func (w *typeWriter) space() {
+	w.tks[len(w.tks)-1].HasSuffixSpace = true
+}
+
+// separator adds a separator such as ", " between elements of a list
+func (w *typeWriter) separator(s string) {
+	w.add(TokenKindPunctuation, s)
+	w.space()
+}
+
+// writeType adds the tokens of t. Named types declared in other packages are qualified by their package's
+// name, and named types declared in the module navigate to their declarations.
+func (w *typeWriter) writeType(t types.Type) {
+	switch t := t.(type) {
+	case *types.Array:
+		w.add(TokenKindPunctuation, "[")
+		w.add(TokenKindLiteral, strconv.FormatInt(t.Len(), 10))
+		w.add(TokenKindPunctuation, "]")
+		w.writeType(t.Elem())
+	case *types.Basic:
+		w.add(TokenKindTypeName, t.Name())
+	case *types.Chan:
+		switch t.Dir() {
+		case types.RecvOnly:
+			w.add(TokenKindKeyword, "<-chan")
+		case types.SendOnly:
+			w.add(TokenKindKeyword, "chan<-")
+		default:
+			w.add(TokenKindKeyword, "chan")
+		}
+		w.space()
+		w.writeType(t.Elem())
+	case *types.Interface:
+		w.writeInterface(t)
+	case *types.Map:
+		w.add(TokenKindKeyword, "map")
+		w.add(TokenKindPunctuation, "[")
+		w.writeType(t.Key())
+		w.add(TokenKindPunctuation, "]")
+		w.writeType(t.Elem())
+	case *types.Pointer:
+		w.add(TokenKindPunctuation, "*")
+		w.writeType(t.Elem())
+	case *types.Signature:
+		w.add(TokenKindKeyword, "func")
+		w.writeSignature(t)
+	case *types.Slice:
+		w.add(TokenKindPunctuation, "[")
+		w.add(TokenKindPunctuation, "]")
+		w.writeType(t.Elem())
+	case *types.Struct:
+		w.add(TokenKindKeyword, "struct")
+		w.add(TokenKindPunctuation, "{")
+		for i := 0; i < t.NumFields(); i++ {
+			if i > 0 {
+				w.separator(";")
+			}
+			f := t.Field(i)
+			if !f.Embedded() {
+				w.add(TokenKindMemberName, f.Name())
+				w.space()
+			}
+			w.writeType(f.Type())
+			if tag := t.Tag(i); tag != "" {
+				w.space()
+				w.add(TokenKindStringLiteral, strconv.Quote(tag))
+			}
+		}
+		w.add(TokenKindPunctuation, "}")
+	case *types.TypeParam:
+		w.add(TokenKindTypeName, t.Obj().Name())
+	case *types.Union:
+		for i := 0; i < t.Len(); i++ {
+			if i > 0 {
+				w.space()
+				w.separator("|")
+			}
+			if t.Term(i).Tilde() {
+				w.add(TokenKindPunctuation, "~")
+			}
+			w.writeType(t.Term(i).Type())
+		}
+	case interface{ Obj() *types.TypeName }:
+		// a defined type, or an alias when the type checker represents aliases explicitly
+		obj := t.Obj()
+		tk := ReviewToken{Kind: TokenKindTypeName, Value: obj.Name()}
+		if obj.Pkg() != nil && obj.Parent() == obj.Pkg().Scope() {
+			if navName, ok := w.pkg.navName(obj.Pkg().Path()); ok {
+				tk.NavigateToID = navName + "." + obj.Name()
+			}
+			if q := w.qualifier(obj.Pkg()); q != "" {
+				tk.Value = q + "." + tk.Value
+			}
+		}
+		w.tks = append(w.tks, tk)
+		if ta, ok := t.(interface{ TypeArgs() *types.TypeList }); ok && ta.TypeArgs().Len() > 0 {
+			args := ta.TypeArgs()
+			w.add(TokenKindPunctuation, "[")
+			for i := 0; i < args.Len(); i++ {
+				if i > 0 {
+					w.separator(",")
+				}
+				w.writeType(args.At(i))
+			}
+			w.add(TokenKindPunctuation, "]")
+		}
+	default:
+		w.add(TokenKindTypeName, types.TypeString(t, w.qualifier))
+	}
+}
+
+// writeInterface adds the tokens of t, an interface type
+func (w *typeWriter) writeInterface(t *types.Interface) {
+	switch {
+	case t == types.Universe.Lookup("any").Type():
+		w.add(TokenKindKeyword, "any")
+		return
+	case t.IsImplicit() && t.NumEmbeddeds() == 1:
+		// a constraint written without "interface" e.g. "~int | ~string" in "[T ~int | ~string]"
+		w.writeType(t.EmbeddedType(0))
+		return
+	}
+	w.add(TokenKindKeyword, "interface")
+	w.add(TokenKindPunctuation, "{")
+	for i := 0; i < t.NumExplicitMethods(); i++ {
+		if i > 0 {
+			w.separator(";")
+		}
+		m := t.ExplicitMethod(i)
+		w.add(TokenKindMemberName, m.Name())
+		w.writeSignature(m.Type().(*types.Signature))
+	}
+	for i := 0; i < t.NumEmbeddeds(); i++ {
+		if i > 0 || t.NumExplicitMethods() > 0 {
+			w.separator(";")
+		}
+		w.writeType(t.EmbeddedType(i))
+	}
+	w.add(TokenKindPunctuation, "}")
+}
+
+// writeSignature adds the tokens of sig's parameters and results
+func (w *typeWriter) writeSignature(sig *types.Signature) {
+	w.add(TokenKindPunctuation, "(")
+	w.writeTuple(sig.Params(), sig.Variadic())
+	w.add(TokenKindPunctuation, ")")
+	if res := sig.Results(); res.Len() == 1 && res.At(0).Name() == "" {
+		w.space()
+		w.writeType(res.At(0).Type())
+	} else if res.Len() > 0 {
+		w.space()
+		w.add(TokenKindPunctuation, "(")
+		w.writeTuple(res, false)
+		w.add(TokenKindPunctuation, ")")
+	}
+}
+
+// writeTuple adds the tokens of the parameters or results in tup, separated by commas
+func (w *typeWriter) writeTuple(tup *types.Tuple, variadic bool) {
+	for i := 0; i < tup.Len(); i++ {
+		if i > 0 {
+			w.separator(",")
+		}
+		v := tup.At(i)
+		if v.Name() != "" {
+			w.add(TokenKindMemberName, v.Name())
+			w.space()
+		}
+		if s, ok := v.Type().(*types.Slice); ok && variadic && i == tup.Len()-1 {
+			w.add(TokenKindPunctuation, "...")
+			w.writeType(s.Elem())
+		} else {
+			w.writeType(v.Type())
+		}
+	}
+}
+
+// navName returns the name relative to the module of the package having import path impPath
+// e.g. "azcore/runtime", or false when that package isn't in the module
+func (pkg Pkg) navName(impPath string) (string, bool) {
+	after, found := strings.CutPrefix(impPath, pkg.modulePath)
+	if !found || (after != "" && !strings.HasPrefix(after, "/")) {
+		return "", false
+	}
+	return strings.TrimSuffix(pkg.relName, strings.TrimPrefix(pkg.importPath, pkg.modulePath)) + after, true
+}
+
+// qualifiersIn returns the names qualifying identifiers from other packages in x, an expression in pkg,
+// keyed by the packages' paths. Identifiers from dot imports have empty qualifiers.
+func (pkg Pkg) qualifiersIn(x ast.Expr) map[string]string {
+	qualifiers := map[string]string{}
+	ast.Inspect(x, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if id, ok := n.X.(*ast.Ident); ok {
+				if pn, ok := pkg.info.Uses[id].(*types.PkgName); ok {
+					qualifiers[pn.Imported().Path()] = id.Name
+					return false
+				}
+			}
+		case *ast.Ident:
+			if obj := pkg.info.Uses[n]; obj != nil && obj.Pkg() != nil && obj.Pkg().Path() != pkg.importPath {
+				if _, ok := obj.(*types.TypeName); ok {
+					qualifiers[obj.Pkg().Path()] = ""
+				}
+			}
+		}
+		return true
+	})
+	return qualifiers
+}
+
+// qualifier returns the name qualifying identifiers from tp in the source declaring the types, as the
+// review describes types without type information. That's empty for identifiers declared in w.pkg or
+// dot imported, the import's name when the file names the import e.g. "ctx" for `import ctx "context"`,
+// and otherwise tp's name.
+func (w *typeWriter) qualifier(tp *types.Package) string {
+	if tp.Path() == w.pkg.importPath {
+		return ""
+	}
+	if q, ok := w.qualifiers[tp.Path()]; ok {
+		return q
+	}
+	q := ""
+	for name, impPath := range w.imports {
+		if impPath != tp.Path() || name == "_" {
+			continue
+		}
+		if name == "." {
+			return ""
+		}
+		// a file may import a package more than once; prefer the name sorting first, for stable output
+		if q == "" || name < q {
+			q = name
+		}
+	}
+	if q == "" || q == filepath.Base(tp.Path()) {
+		// fileImports names unnamed imports by the last element of their path, which may not be the package name
+		return tp.Name()
+	}
+	return q
+}
@@ -0,0 +1,137 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// workspace describes where the go command would find the source of modules required by the reviewed
+// module, according to the reviewed module's replace directives and any go.work file
+type workspace struct {
+	// uses maps the paths of the modules in go.work use directives to their directories
+	uses map[string]string
+	// replaces are the replace directives of go.work and then the reviewed module's go.mod. As with the
+	// go command, go.work's directives take precedence.
+	replaces []replacement
+}
+
+// replacement is a replace directive
+type replacement struct {
+	*modfile.Replace
+	// dir is the directory of the file declaring the directive, to which local paths are relative
+	dir string
+	// inGoWork indicates go.work declares the directive
+	inGoWork bool
+}
+
+// loadWorkspace returns the workspace of the module in dir, whose go.mod is mf. It finds go.work as the
+// go command would, in $GOWORK or else dir or one of its parents, and ignores it when GOWORK=off.
+func loadWorkspace(dir string, mf *modfile.File) (*workspace, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	w := &workspace{uses: map[string]string{}}
+	p, err := findGoWork(dir)
+	if err != nil {
+		return nil, err
+	}
+	if p != "" {
+		logger.Debug("using workspace", "gowork", p)
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		wf, err := modfile.ParseWork(p, content, nil)
+		if err != nil {
+			return nil, err
+		}
+		wd := filepath.Dir(p)
+		for _, u := range wf.Use {
+			d := localPath(wd, u.Path)
+			umf, err := parseModFile(d)
+			if err != nil {
+				return nil, fmt.Errorf("%s uses %s: %w", p, u.Path, err)
+			}
+			w.uses[umf.Module.Mod.Path] = d
+		}
+		for _, r := range wf.Replace {
+			w.replaces = append(w.replaces, replacement{Replace: r, dir: wd, inGoWork: true})
+		}
+	}
+	for _, r := range mf.Replace {
+		w.replaces = append(w.replaces, replacement{Replace: r, dir: dir})
+	}
+	return w, nil
+}
+
+// findGoWork returns the path of the go.work file applying to the module in dir, or an empty string
+// when there's no such file
+func findGoWork(dir string) (string, error) {
+	switch gowork := os.Getenv("GOWORK"); gowork {
+	case "off":
+		return "", nil
+	case "":
+	default:
+		return gowork, nil
+	}
+	for {
+		p := filepath.Join(dir, "go.work")
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// locate returns the source of mod. That's a directory when a go.work use directive lists mod, or a
+// replace directive replaces it with a local path. Otherwise, it's the module version replacing mod,
+// or mod itself when no directive applies.
+func (w *workspace) locate(mod module.Version) (string, module.Version) {
+	if d, ok := w.uses[mod.Path]; ok {
+		return d, module.Version{}
+	}
+	var match *replacement
+	for i, r := range w.replaces {
+		if r.Old.Path != mod.Path || (r.Old.Version != "" && r.Old.Version != mod.Version) {
+			continue
+		}
+		// as with the go command, a directive for a particular version beats one for all versions
+		// declared in the same file
+		if match == nil || (match.inGoWork == r.inGoWork && match.Old.Version == "" && r.Old.Version != "") {
+			match = &w.replaces[i]
+		}
+	}
+	switch {
+	case match == nil:
+		return "", mod
+	case match.New.Version == "":
+		return localPath(match.dir, match.New.Path), module.Version{}
+	default:
+		return "", match.New
+	}
+}
+
+// localPath returns p, a path in a go.mod or go.work file, relative to dir if it isn't absolute
+func localPath(dir, p string) string {
+	p = filepath.FromSlash(p)
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	return p
+}